@@ -2,56 +2,135 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"spot_grid_bot/pkg/api"
+	"spot_grid_bot/pkg/backtest"
 	"spot_grid_bot/pkg/bot"
 	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/metrics"
+	"spot_grid_bot/pkg/store"
+	"spot_grid_bot/pkg/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "backtest":
+			runBacktest(os.Args[2:])
+			return
+		case "sweep":
+			runSweep(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command line flags
-	symbol := flag.String("symbol", "BTCUSDT", "Trading pair symbol")
-	lowerPrice := flag.Float64("lower", 0, "Lower price bound")
-	upperPrice := flag.Float64("upper", 0, "Upper price bound")
-	gridNum := flag.Int("grids", 5, "Number of grid levels")
-	investment := flag.Float64("investment", 0, "Total investment amount in quote currency")
+	gridOpts := registerGridFlags(flag.CommandLine)
+	triggerOpts := registerTriggerFlags(flag.CommandLine)
+	acquireBase := flag.Bool("acquire-base", false, "Market buy the base asset needed for the sell orders on start")
+	statePath := flag.String("state", "", "File to persist bot state to, enabling resume after restart")
+	stateBackend := flag.String("state-backend", "json", "State store backend: json or bolt")
+	paper := flag.Bool("paper", false, "Paper trade against live testnet prices with in-memory balances; no API credentials needed")
+	paperBase := flag.Float64("paper-base", 0, "Base asset held at the start of paper trading in addition to the investment")
+	listen := flag.String("listen", "", "Address to serve the HTTP status and control API and Prometheus metrics on, e.g. localhost:8080")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "Log format: text or json")
 	flag.Parse()
 
-	// Validate required flags
-	if *lowerPrice == 0 || *upperPrice == 0 || *investment == 0 {
-		log.Fatal("Lower price, upper price, and investment amount are required")
+	// Set up structured logging
+	logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
 	}
+	slog.SetDefault(logger)
 
-	// Get API credentials from environment variables
-	apiKey := os.Getenv("BINANCE_TEST_API_KEY")
-	apiSecret := os.Getenv("BINANCE_TEST_API_SECRET")
-	if apiKey == "" || apiSecret == "" {
-		log.Fatal("BINANCE_TEST_API_KEY and BINANCE_TEST_API_SECRET environment variables are required")
+	// Validate required flags
+	config, err := gridOpts.config()
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	config.AcquireBase = *acquireBase
+	if err := triggerOpts.apply(&config); err != nil {
+		fatal("Invalid configuration", "error", err)
 	}
 
-	// Initialize Binance client
-	client, err := exchange.NewBinanceClient(apiKey, apiSecret)
-	if err != nil {
-		log.Fatalf("Failed to create Binance client: %v", err)
+	// Record exchange API latency and errors for /metrics
+	registry := prometheus.NewRegistry()
+	requestMetrics := metrics.NewRequestMetrics(registry)
+
+	// Initialize the exchange
+	var client bot.Exchange
+	var paperExchange *exchange.PaperExchange
+	var feed exchange.PriceFeed
+	if *paper {
+		public := exchange.NewPublicBinanceClient()
+		public.SetRequestObserver(requestMetrics)
+		paperExchange, err = newPaperExchange(public, config, *paperBase)
+		if err != nil {
+			fatal("Failed to create paper exchange", "error", err)
+		}
+		client, feed = paperExchange, public
+	} else {
+		// Get API credentials from environment variables
+		apiKey := os.Getenv("BINANCE_TEST_API_KEY")
+		apiSecret := os.Getenv("BINANCE_TEST_API_SECRET")
+		if apiKey == "" || apiSecret == "" {
+			fatal("BINANCE_TEST_API_KEY and BINANCE_TEST_API_SECRET environment variables are required")
+		}
+
+		// Initialize Binance client
+		binanceClient, err := exchange.NewBinanceClient(apiKey, apiSecret)
+		if err != nil {
+			fatal("Failed to create Binance client", "error", err)
+		}
+		binanceClient.SetRequestObserver(requestMetrics)
+		client = binanceClient
 	}
 
-	// Create bot configuration
-	config := bot.GridBotConfig{
-		Symbol:     *symbol,
-		LowerPrice: *lowerPrice,
-		UpperPrice: *upperPrice,
-		GridNum:    *gridNum,
-		Investment: *investment,
+	// Set up persistent state
+	var opts []bot.Option
+	if *statePath != "" {
+		var stateStore bot.StateStore
+		switch *stateBackend {
+		case "json":
+			stateStore, err = store.NewJSONFileStore(*statePath)
+		case "bolt":
+			var boltStore *store.BoltStore
+			boltStore, err = store.OpenBoltStore(*statePath)
+			if err == nil {
+				defer boltStore.Close()
+			}
+			stateStore = boltStore
+		default:
+			fatal("Unknown state backend", "backend", *stateBackend)
+		}
+		if err != nil {
+			fatal("Failed to open state store", "error", err)
+		}
+		opts = append(opts, bot.WithStateStore(stateStore))
 	}
 
 	// Create grid bot
-	gridBot, err := bot.NewGridBot(client, config)
+	gridBot, err := bot.NewGridBot(client, config, opts...)
 	if err != nil {
-		log.Fatalf("Failed to create grid bot: %v", err)
+		fatal("Failed to create grid bot", "error", err)
 	}
 
 	// Create context that will be canceled on interrupt
@@ -63,26 +142,519 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		log.Println("Shutting down...")
+		slog.Info("Shutting down")
 		cancel()
 	}()
 
+	// Feed live prices to the paper exchange
+	if paperExchange != nil {
+		go func() {
+			if err := paperExchange.Run(ctx, feed); err != nil && ctx.Err() == nil {
+				slog.Error("Paper price feed stopped", "error", err)
+			}
+		}()
+	}
+
 	// Start the bot
-	log.Printf("Starting grid bot for %s...", *symbol)
-	log.Printf("Grid configuration: Lower: %.2f, Upper: %.2f, Grids: %d, Investment: %.2f",
-		*lowerPrice, *upperPrice, *gridNum, *investment)
+	status := gridBot.GetStatus()
+	slog.Info("Starting grid bot", "symbol", config.Symbol,
+		"lowerPrice", status.Config.LowerPrice, "upperPrice", status.Config.UpperPrice, "gridNum", status.Config.GridNum,
+		"spacing", config.Spacing, "sizing", config.Sizing, "investment", config.Investment)
 
 	if err := gridBot.Start(ctx); err != nil {
-		log.Fatalf("Failed to start grid bot: %v", err)
+		fatal("Failed to start grid bot", "error", err)
+	}
+
+	// Serve the status and control API and the metrics
+	var stopped <-chan struct{}
+	if *listen != "" {
+		token := os.Getenv("GRID_BOT_API_TOKEN")
+		if token == "" {
+			slog.Warn("GRID_BOT_API_TOKEN is not set, control endpoints are disabled")
+		}
+		apiServer := api.NewServer(gridBot, token)
+		stopped = apiServer.Stopped()
+
+		metrics.RegisterBot(registry, gridBot)
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		mux.Handle("/", apiServer)
+
+		httpServer := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			slog.Info("Serving API", "address", *listen)
+			if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("API server failed", "error", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+	}
+
+	// Wait for context cancellation, a stop through the API or a stop-loss
+	// or take-profit
+	select {
+	case <-ctx.Done():
+		// Stop the bot
+		if err := gridBot.Stop(context.Background()); err != nil {
+			slog.Error("Error stopping bot", "error", err)
+		}
+	case <-stopped:
+		slog.Info("Bot stopped through the API")
+	case <-gridBot.Stopped():
+		status := gridBot.GetStatus()
+		slog.Info("Bot stopped", "reason", status.StopReason, "lastPrice", status.LastPrice,
+			"netPnL", status.PnL.NetPnL)
+	}
+
+	slog.Info("Bot stopped successfully")
+}
+
+// newLogger creates a logger writing records at or above level to w in the
+// given format
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q: must be text or json", format)
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// newPaperExchange creates a paper exchange for the bot's symbol holding the
+// investment in the quote asset and base in the base asset, charging the bot's
+// fees, with the symbol's trading rules and current price taken from client
+func newPaperExchange(client *exchange.BinanceClient, config bot.GridBotConfig, base float64) (*exchange.PaperExchange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := client.GetSymbolInfo(ctx, config.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	price, err := client.GetSymbolPrice(ctx, config.Symbol)
+	if err != nil {
+		return nil, err
 	}
 
-	// Wait for context cancellation
-	<-ctx.Done()
+	paperExchange, err := exchange.NewPaperExchange(exchange.SimulatorConfig{
+		Symbol: info,
+		Balances: map[string]float64{
+			info.QuoteAsset: config.Investment,
+			info.BaseAsset:  base,
+		},
+		MakerFee: config.Fees.Maker(),
+		TakerFee: config.Fees.Taker(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	paperExchange.SetPrice(types.PriceUpdate{Symbol: config.Symbol, Price: price})
+	return paperExchange, nil
+}
+
+// gridFlags holds the grid parameters shared by the bot and the backtest
+type gridFlags struct {
+	symbol         *string
+	lowerPrice     *float64
+	upperPrice     *float64
+	gridNum        *int
+	spacing        *string
+	levelList      *string
+	centerPrice    *float64
+	concentration  *float64
+	investment     *float64
+	sizing         *string
+	sizeMultiplier *float64
+	trailing       *string
+	trailUpper     *float64
+	trailLower     *float64
+	fees           *feeFlags
+}
+
+// feeFlags holds the trading fee and grid profitability parameters
+type feeFlags struct {
+	makerFee      *float64
+	takerFee      *float64
+	bnbDiscount   *float64
+	minGridProfit *float64
+	profitPolicy  *string
+}
+
+// registerFeeFlags defines the fee flags on fs
+func registerFeeFlags(fs *flag.FlagSet) *feeFlags {
+	return &feeFlags{
+		makerFee:      fs.Float64("maker-fee", grid.DefaultFeeRate, "Maker fee rate, e.g. 0.001 for 0.1%"),
+		takerFee:      fs.Float64("taker-fee", grid.DefaultFeeRate, "Taker fee rate"),
+		bnbDiscount:   fs.Float64("bnb-discount", 0, "Fee discount for paying fees in BNB, e.g. 0.25 for 25%"),
+		minGridProfit: fs.Float64("min-grid-profit", 0, "Minimum net profit per grid round trip after fees, e.g. 0.002 for 0.2%"),
+		profitPolicy:  fs.String("profit-check", "reject", "Grids below the minimum profit: reject or warn"),
+	}
+}
+
+// apply validates the fee flags and sets them on config
+func (f *feeFlags) apply(config *bot.GridBotConfig) error {
+	profitPolicy, err := grid.ParseProfitPolicy(*f.profitPolicy)
+	if err != nil {
+		return err
+	}
+	config.Fees = grid.Fees{
+		MakerRate:   *f.makerFee,
+		TakerRate:   *f.takerFee,
+		BNBDiscount: *f.bnbDiscount,
+	}
+	config.MinGridProfit = *f.minGridProfit
+	config.ProfitPolicy = profitPolicy
+	return nil
+}
+
+// triggerFlags holds the stop-loss and take-profit parameters
+type triggerFlags struct {
+	stopLoss         *float64
+	takeProfit       *float64
+	stopLossAction   *string
+	takeProfitAction *string
+}
+
+// registerTriggerFlags defines the stop-loss and take-profit flags on fs
+func registerTriggerFlags(fs *flag.FlagSet) *triggerFlags {
+	return &triggerFlags{
+		stopLoss:         fs.Float64("stop-loss", 0, "Stop the grid when the price falls to this price (0 disables)"),
+		takeProfit:       fs.Float64("take-profit", 0, "Stop the grid when the price rises to this price (0 disables)"),
+		stopLossAction:   fs.String("stop-loss-action", "none", "After a stop-loss cancels the grid: none, sell the inventory or buy with the reserved quote"),
+		takeProfitAction: fs.String("take-profit-action", "none", "After a take-profit cancels the grid: none, sell the inventory or buy with the reserved quote"),
+	}
+}
+
+// apply validates the trigger flags and sets them on config
+func (f *triggerFlags) apply(config *bot.GridBotConfig) error {
+	stopLossAction, err := bot.ParseStopAction(*f.stopLossAction)
+	if err != nil {
+		return err
+	}
+	takeProfitAction, err := bot.ParseStopAction(*f.takeProfitAction)
+	if err != nil {
+		return err
+	}
+	config.StopLossPrice = *f.stopLoss
+	config.TakeProfitPrice = *f.takeProfit
+	config.StopLossAction = stopLossAction
+	config.TakeProfitAction = takeProfitAction
+	return nil
+}
+
+// registerGridFlags defines the grid parameter flags on fs
+func registerGridFlags(fs *flag.FlagSet) *gridFlags {
+	return &gridFlags{
+		symbol:         fs.String("symbol", "BTCUSDT", "Trading pair symbol"),
+		lowerPrice:     fs.Float64("lower", 0, "Lower price bound"),
+		upperPrice:     fs.Float64("upper", 0, "Upper price bound"),
+		gridNum:        fs.Int("grids", 5, "Number of grid levels"),
+		spacing:        fs.String("spacing", "arithmetic", "Grid level spacing: arithmetic, geometric, explicit or weighted"),
+		levelList:      fs.String("levels", "", "Comma-separated price levels for explicit spacing"),
+		centerPrice:    fs.Float64("center", 0, "Price to concentrate levels around for weighted spacing (default: mid of the range)"),
+		concentration:  fs.Float64("concentration", 0, "How strongly weighted levels cluster around the center, >= 1 (default 2)"),
+		investment:     fs.Float64("investment", 0, "Total investment amount in quote currency"),
+		sizing:         fs.String("sizing", "equal-quote", "Position sizing: equal-quote, equal-base or martingale"),
+		sizeMultiplier: fs.Float64("size-multiplier", 0, "Per-level size growth toward the range edges for martingale sizing, >= 1 (default 1.5)"),
+		trailing:       fs.String("trailing", "off", "Follow the price out of the range by moving the grid: off, up, down or both"),
+		trailUpper:     fs.Float64("trail-upper-limit", 0, "Highest level a trailing grid may move up to (0 for no limit)"),
+		trailLower:     fs.Float64("trail-lower-limit", 0, "Lowest level a trailing grid may move down to (0 for no limit)"),
+		fees:           registerFeeFlags(fs),
+	}
+}
 
-	// Stop the bot
-	if err := gridBot.Stop(context.Background()); err != nil {
-		log.Printf("Error stopping bot: %v", err)
+// config validates the grid flags and builds a bot configuration from them
+func (f *gridFlags) config() (bot.GridBotConfig, error) {
+	gridSpacing, err := grid.ParseSpacing(*f.spacing)
+	if err != nil {
+		return bot.GridBotConfig{}, err
+	}
+	positionSizing, err := grid.ParseSizing(*f.sizing)
+	if err != nil {
+		return bot.GridBotConfig{}, err
+	}
+	trailing, err := bot.ParseTrailMode(*f.trailing)
+	if err != nil {
+		return bot.GridBotConfig{}, err
+	}
+	var levels []float64
+	if gridSpacing == grid.SpacingExplicit {
+		if levels, err = parseLevels(*f.levelList); err != nil {
+			return bot.GridBotConfig{}, err
+		}
+		if *f.investment == 0 {
+			return bot.GridBotConfig{}, fmt.Errorf("investment amount is required")
+		}
+	} else if *f.lowerPrice == 0 || *f.upperPrice == 0 || *f.investment == 0 {
+		return bot.GridBotConfig{}, fmt.Errorf("lower price, upper price, and investment amount are required")
 	}
 
-	log.Println("Bot stopped successfully")
+	config := bot.GridBotConfig{
+		Symbol:     *f.symbol,
+		LowerPrice: *f.lowerPrice,
+		UpperPrice: *f.upperPrice,
+		GridNum:    *f.gridNum,
+		Investment: *f.investment,
+		Spacing:    gridSpacing,
+
+		Levels:        levels,
+		CenterPrice:   *f.centerPrice,
+		Concentration: *f.concentration,
+
+		Sizing:         positionSizing,
+		SizeMultiplier: *f.sizeMultiplier,
+
+		Trailing:        trailing,
+		TrailUpperLimit: *f.trailUpper,
+		TrailLowerLimit: *f.trailLower,
+	}
+	if err := f.fees.apply(&config); err != nil {
+		return bot.GridBotConfig{}, err
+	}
+	return config, nil
+}
+
+// simulationFlags holds the parameters shared by the backtest and sweep
+// subcommands
+type simulationFlags struct {
+	dataPath    *string
+	initialBase *float64
+	tickSize    *float64
+	stepSize    *float64
+	minNotional *float64
+}
+
+// registerSimulationFlags defines the simulation flags on fs
+func registerSimulationFlags(fs *flag.FlagSet) *simulationFlags {
+	return &simulationFlags{
+		dataPath:    fs.String("data", "", "Candle file, CSV or Binance kline JSON (.json)"),
+		initialBase: fs.Float64("initial-base", 0, "Base asset held at the start in addition to the investment"),
+		tickSize:    fs.Float64("tick-size", 0, "Price tick size of the symbol (default: no rounding)"),
+		stepSize:    fs.Float64("step-size", 0, "Quantity step size of the symbol (default: no rounding)"),
+		minNotional: fs.Float64("min-notional", 0, "Minimum order value of the symbol"),
+	}
+}
+
+// load reads the candles to simulate
+func (f *simulationFlags) load() ([]backtest.Candle, error) {
+	if *f.dataPath == "" {
+		return nil, fmt.Errorf("-data is required")
+	}
+	candles, err := backtest.LoadCandles(*f.dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load candles: %w", err)
+	}
+	return candles, nil
+}
+
+// config builds a backtest configuration around a bot configuration
+func (f *simulationFlags) config(botConfig bot.GridBotConfig) backtest.Config {
+	return backtest.Config{
+		Bot: botConfig,
+		Symbol: types.SymbolInfo{
+			Symbol:      botConfig.Symbol,
+			TickSize:    *f.tickSize,
+			StepSize:    *f.stepSize,
+			MinNotional: *f.minNotional,
+		},
+		InitialBase: *f.initialBase,
+		// Keep the per-order log lines out of the report
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+// runBacktest implements the backtest subcommand: it replays historical
+// candles through a simulated exchange and prints a report
+func runBacktest(args []string) {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	gridOpts := registerGridFlags(fs)
+	simOpts := registerSimulationFlags(fs)
+	jsonOutput := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	config, err := gridOpts.config()
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	candles, err := simOpts.load()
+	if err != nil {
+		fatal("Failed to load candles", "error", err)
+	}
+
+	report, err := backtest.Run(context.Background(), simOpts.config(config), candles)
+	if err != nil {
+		fatal("Backtest failed", "error", err)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fatal("Failed to write report", "error", err)
+	}
+}
+
+// runSweep implements the sweep subcommand: it backtests every combination
+// of the given grid parameters and prints them ranked
+func runSweep(args []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	symbol := fs.String("symbol", "BTCUSDT", "Trading pair symbol")
+	lowerList := fs.String("lower", "", "Lower price bounds: a list (25000,26000) or a range with step (24000:28000:1000)")
+	upperList := fs.String("upper", "", "Upper price bounds, as for -lower")
+	gridList := fs.String("grids", "5", "Numbers of grid levels, as for -lower")
+	spacingList := fs.String("spacing", "arithmetic", "Comma-separated spacings: arithmetic, geometric or weighted")
+	sizingList := fs.String("sizing", "equal-quote", "Comma-separated sizings: equal-quote, equal-base or martingale")
+	investment := fs.Float64("investment", 0, "Total investment amount in quote currency")
+	feeOpts := registerFeeFlags(fs)
+	simOpts := registerSimulationFlags(fs)
+	workers := fs.Int("workers", runtime.NumCPU(), "Backtests to run in parallel")
+	rankBy := fs.String("rank", "return", "Metric to rank by: return, sharpe or drawdown")
+	format := fs.String("format", "csv", "Output format: csv or json")
+	top := fs.Int("top", 0, "Only print the best N results (default: all)")
+	fs.Parse(args)
+
+	if *investment == 0 {
+		fatal("Invalid configuration", "error", "investment amount is required")
+	}
+	if *format != "csv" && *format != "json" {
+		fatal("Invalid configuration", "error", fmt.Sprintf("unknown output format %q", *format))
+	}
+	rank, err := backtest.ParseRank(*rankBy)
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+
+	botConfig := bot.GridBotConfig{Symbol: *symbol, Investment: *investment}
+	if err := feeOpts.apply(&botConfig); err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	config := backtest.SweepConfig{
+		Base:    simOpts.config(botConfig),
+		Workers: *workers,
+	}
+	if config.LowerPrices, err = parseRange("-lower", *lowerList); err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	if config.UpperPrices, err = parseRange("-upper", *upperList); err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	gridNums, err := parseRange("-grids", *gridList)
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	for _, n := range gridNums {
+		config.GridNums = append(config.GridNums, int(n))
+	}
+	for _, name := range strings.Split(*spacingList, ",") {
+		spacing, err := grid.ParseSpacing(strings.TrimSpace(name))
+		if err != nil {
+			fatal("Invalid configuration", "error", err)
+		}
+		config.Spacings = append(config.Spacings, spacing)
+	}
+	for _, name := range strings.Split(*sizingList, ",") {
+		sizing, err := grid.ParseSizing(strings.TrimSpace(name))
+		if err != nil {
+			fatal("Invalid configuration", "error", err)
+		}
+		config.Sizings = append(config.Sizings, sizing)
+	}
+
+	candles, err := simOpts.load()
+	if err != nil {
+		fatal("Failed to load candles", "error", err)
+	}
+
+	results, err := backtest.Sweep(context.Background(), config, candles, rank)
+	if err != nil {
+		fatal("Sweep failed", "error", err)
+	}
+	if *top > 0 && *top < len(results) {
+		results = results[:*top]
+	}
+
+	if *format == "json" {
+		err = backtest.WriteSweepJSON(os.Stdout, results)
+	} else {
+		err = backtest.WriteSweepCSV(os.Stdout, results)
+	}
+	if err != nil {
+		fatal("Failed to write results", "error", err)
+	}
+}
+
+// parseRange parses a comma-separated list of numbers or an inclusive
+// start:end:step range
+func parseRange(name, value string) ([]float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("%s is required", name)
+	}
+
+	if parts := strings.Split(value, ":"); len(parts) == 3 {
+		var bounds [3]float64
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s range %q: %w", name, value, err)
+			}
+			bounds[i] = v
+		}
+		start, end, step := bounds[0], bounds[1], bounds[2]
+		if step <= 0 || end < start {
+			return nil, fmt.Errorf("invalid %s range %q: expected start:end:step with start <= end and a positive step", name, value)
+		}
+		var values []float64
+		// Allow for rounding error in the last step
+		for i := 0; start+float64(i)*step <= end+step*1e-9; i++ {
+			values = append(values, start+float64(i)*step)
+		}
+		return values, nil
+	}
+
+	var values []float64
+	for _, field := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %w", name, field, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parseLevels parses a comma-separated list of prices
+func parseLevels(list string) ([]float64, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, fmt.Errorf("-levels is required for explicit spacing")
+	}
+	var levels []float64
+	for _, field := range strings.Split(list, ",") {
+		price, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level %q: %w", field, err)
+		}
+		levels = append(levels, price)
+	}
+	return levels, nil
 }
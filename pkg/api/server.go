@@ -0,0 +1,184 @@
+// Package api serves a grid bot's state and controls over HTTP
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"spot_grid_bot/pkg/bot"
+)
+
+// maxBodySize bounds the size of request bodies
+const maxBodySize = 1 << 20
+
+// Server exposes a grid bot over HTTP. Read-only endpoints are open; control
+// endpoints require the token as a bearer token and are disabled without one.
+//
+//	GET  /status  bot.Status snapshot
+//	GET  /orders  open orders
+//	GET  /fills   filled orders
+//	GET  /pnl     profit and loss
+//	POST /pause   cancel all orders and stop trading
+//	POST /resume  place the grid again after a pause
+//	POST /stop    cancel all orders and stop the bot
+//	POST /grid    reconfigure the grid; the body is a partial bot.GridBotConfig
+type Server struct {
+	bot     *bot.GridBot
+	token   string
+	handler http.Handler
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// NewServer creates a server controlling gridBot. An empty token disables the
+// control endpoints.
+func NewServer(gridBot *bot.GridBot, token string) *Server {
+	s := &Server{
+		bot:     gridBot,
+		token:   token,
+		stopped: make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.bot.GetStatus())
+	})
+	mux.HandleFunc("GET /orders", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.bot.OpenOrders())
+	})
+	mux.HandleFunc("GET /fills", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.bot.Fills())
+	})
+	mux.HandleFunc("GET /pnl", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.bot.PnL())
+	})
+	mux.HandleFunc("POST /pause", s.authorized(s.pause))
+	mux.HandleFunc("POST /resume", s.authorized(s.resume))
+	mux.HandleFunc("POST /stop", s.authorized(s.stop))
+	mux.HandleFunc("POST /grid", s.authorized(s.reconfigure))
+	s.handler = mux
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Stopped is closed once the bot has been stopped through the API
+func (s *Server) Stopped() <-chan struct{} {
+	return s.stopped
+}
+
+// authorized rejects requests that do not carry the server's token
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" {
+			writeError(w, http.StatusForbidden, errors.New("control endpoints are disabled"))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// pause pauses the bot, leaving its orders on the book with ?keepOrders=true
+func (s *Server) pause(w http.ResponseWriter, r *http.Request) {
+	var keepOrders bool
+	if value := r.URL.Query().Get("keepOrders"); value != "" {
+		var err error
+		if keepOrders, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid keepOrders %q: %w", value, err))
+			return
+		}
+	}
+	if err := s.bot.Pause(r.Context(), keepOrders); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bot.GetStatus())
+}
+
+func (s *Server) resume(w http.ResponseWriter, r *http.Request) {
+	if err := s.bot.Resume(r.Context()); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bot.GetStatus())
+}
+
+func (s *Server) stop(w http.ResponseWriter, r *http.Request) {
+	err := s.bot.Stop(r.Context())
+	if errors.Is(err, bot.ErrNotRunning) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	// The bot is stopped even if some orders could not be canceled
+	s.stopOnce.Do(func() { close(s.stopped) })
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bot.GetStatus())
+}
+
+// reconfigure applies the fields present in the request body to the current
+// configuration
+func (s *Server) reconfigure(w http.ResponseWriter, r *http.Request) {
+	config := s.bot.GetStatus().Config
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.bot.Reconfigure(r.Context(), config); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bot.GetStatus())
+}
+
+// errorStatus maps a control error to an HTTP status code
+func errorStatus(err error) int {
+	var balanceErr *bot.InsufficientBalanceError
+	var configErr *bot.ConfigError
+	switch {
+	case errors.Is(err, bot.ErrNotRunning), errors.Is(err, bot.ErrPaused), errors.Is(err, bot.ErrNotPaused):
+		return http.StatusConflict
+	case errors.As(err, &balanceErr):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &configErr):
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write API response", "error", err)
+	}
+}
+
+// writeError writes err as a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/types"
+)
+
+const testToken = "secret"
+
+// newTestServer starts a bot trading on a simulated exchange and serves it
+func newTestServer(t *testing.T, token string) (*httptest.Server, *Server, *bot.GridBot, *exchange.SimulatedExchange) {
+	t.Helper()
+
+	sim, err := exchange.NewSimulatedExchange(exchange.SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 1000, "BTC": 0.05},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create simulated exchange: %v", err)
+	}
+	sim.SetPrice(time.Now(), 31000)
+
+	gridBot, err := bot.NewGridBot(sim, bot.GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000,
+		UpperPrice:   35000,
+		GridNum:      5,
+		Investment:   1000,
+		PollInterval: time.Hour, // Fills are checked manually
+	})
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	ctx := context.Background()
+	if err := gridBot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	t.Cleanup(func() { gridBot.Stop(ctx) })
+
+	server := NewServer(gridBot, token)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return httpServer, server, gridBot, sim
+}
+
+// do sends a request and decodes the JSON response into out, if not nil
+func do(t *testing.T, server *httptest.Server, method, path, token, body string, out any) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("Failed to decode %s %s response %q: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServerReadEndpoints(t *testing.T) {
+	server, _, gridBot, sim := newTestServer(t, testToken)
+
+	sim.SetPrice(time.Now(), 27000)
+	gridBot.CheckOrders(context.Background())
+
+	var status bot.Status
+	if code := do(t, server, http.MethodGet, "/status", "", "", &status); code != http.StatusOK {
+		t.Fatalf("GET /status = %d", code)
+	}
+	if !status.Running || status.Config.Symbol != "BTCUSDT" || len(status.Levels) != 5 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	var orders []bot.OrderRecord
+	if code := do(t, server, http.MethodGet, "/orders", "", "", &orders); code != http.StatusOK {
+		t.Fatalf("GET /orders = %d", code)
+	}
+	if len(orders) != status.OpenOrders {
+		t.Errorf("Expected %d orders, got %d", status.OpenOrders, len(orders))
+	}
+
+	var fills []bot.Fill
+	if code := do(t, server, http.MethodGet, "/fills", "", "", &fills); code != http.StatusOK {
+		t.Fatalf("GET /fills = %d", code)
+	}
+	if len(fills) != 1 || fills[0].Side != "BUY" || fills[0].Price != 27500 {
+		t.Errorf("Expected a buy fill at 27500, got %+v", fills)
+	}
+
+	var pnl bot.PnL
+	if code := do(t, server, http.MethodGet, "/pnl", "", "", &pnl); code != http.StatusOK {
+		t.Fatalf("GET /pnl = %d", code)
+	}
+	if pnl.Inventory <= 0 {
+		t.Errorf("Expected inventory after a buy fill, got %+v", pnl)
+	}
+
+	if code := do(t, server, http.MethodPost, "/status", "", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /status = %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServerControlEndpoints(t *testing.T) {
+	server, _, gridBot, _ := newTestServer(t, testToken)
+
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		body     string
+		wantCode int
+		check    func(t *testing.T, status bot.Status)
+	}{
+		{name: "Missing token", path: "/pause", wantCode: http.StatusUnauthorized},
+		{name: "Wrong token", path: "/pause", token: "wrong", wantCode: http.StatusUnauthorized},
+		{name: "Resume while trading", path: "/resume", token: testToken, wantCode: http.StatusConflict},
+		{
+			name:     "Pause",
+			path:     "/pause",
+			token:    testToken,
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, status bot.Status) {
+				if !status.Paused || status.OpenOrders != 0 {
+					t.Errorf("Expected a paused bot without orders, got paused %v with %d orders",
+						status.Paused, status.OpenOrders)
+				}
+			},
+		},
+		{name: "Pause while paused", path: "/pause", token: testToken, wantCode: http.StatusConflict},
+		{
+			name:     "Resume",
+			path:     "/resume",
+			token:    testToken,
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, status bot.Status) {
+				if status.Paused || status.OpenOrders != 4 {
+					t.Errorf("Expected 4 orders after resuming, got %d (paused %v)", status.OpenOrders, status.Paused)
+				}
+			},
+		},
+		{name: "Invalid keepOrders", path: "/pause?keepOrders=maybe", token: testToken, wantCode: http.StatusBadRequest},
+		{
+			name:     "Pause keeping orders",
+			path:     "/pause?keepOrders=true",
+			token:    testToken,
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, status bot.Status) {
+				if status.State != bot.RunStatePaused || status.OpenOrders != 4 {
+					t.Errorf("Expected a paused bot with 4 orders, got state %s with %d orders",
+						status.State, status.OpenOrders)
+				}
+			},
+		},
+		{
+			name:     "Resume with kept orders",
+			path:     "/resume",
+			token:    testToken,
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, status bot.Status) {
+				if status.State != bot.RunStateRunning || status.OpenOrders != 4 {
+					t.Errorf("Expected a running bot with 4 orders, got state %s with %d orders",
+						status.State, status.OpenOrders)
+				}
+			},
+		},
+		{name: "Invalid grid", path: "/grid", token: testToken, body: `{"gridNum": 1}`, wantCode: http.StatusBadRequest},
+		{name: "Unknown field", path: "/grid", token: testToken, body: `{"grids": 7}`, wantCode: http.StatusBadRequest},
+		{name: "Poll interval too short", path: "/grid", token: testToken, body: `{"pollInterval": 1}`, wantCode: http.StatusBadRequest},
+		{
+			name:     "Reconfigure grid",
+			path:     "/grid",
+			token:    testToken,
+			body:     `{"lowerPrice": 26000, "upperPrice": 36000}`,
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, status bot.Status) {
+				if status.Config.LowerPrice != 26000 || status.Config.UpperPrice != 36000 || status.Config.GridNum != 5 {
+					t.Errorf("Expected grid 26000-36000 with 5 levels, got %+v", status.Config)
+				}
+				if status.Levels[0].Side != "BUY" {
+					t.Errorf("Expected a buy order at the new lower bound, got %+v", status.Levels[0])
+				}
+			},
+		},
+		{
+			name:     "Stop",
+			path:     "/stop",
+			token:    testToken,
+			wantCode: http.StatusOK,
+			check: func(t *testing.T, status bot.Status) {
+				if status.State != bot.RunStateStopped || status.Running || status.OpenOrders != 0 {
+					t.Errorf("Expected a stopped bot without orders, got state %s with %d orders",
+						status.State, status.OpenOrders)
+				}
+			},
+		},
+		{name: "Stop while stopped", path: "/stop", token: testToken, wantCode: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status bot.Status
+			code := do(t, server, http.MethodPost, tt.path, tt.token, tt.body, &status)
+			if code != tt.wantCode {
+				t.Fatalf("POST %s = %d, want %d", tt.path, code, tt.wantCode)
+			}
+			if tt.check != nil {
+				tt.check(t, status)
+			}
+		})
+	}
+
+	if gridBot.GetStatus().Running {
+		t.Error("Expected the bot to be stopped")
+	}
+}
+
+func TestServerStopped(t *testing.T) {
+	server, api, _, _ := newTestServer(t, testToken)
+
+	select {
+	case <-api.Stopped():
+		t.Fatal("Stopped closed before the bot was stopped")
+	default:
+	}
+
+	if code := do(t, server, http.MethodPost, "/stop", testToken, "", nil); code != http.StatusOK {
+		t.Fatalf("POST /stop = %d", code)
+	}
+	select {
+	case <-api.Stopped():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stopped not closed after stopping the bot")
+	}
+}
+
+func TestServerWithoutToken(t *testing.T) {
+	server, _, _, _ := newTestServer(t, "")
+
+	if code := do(t, server, http.MethodPost, "/pause", "", "", nil); code != http.StatusForbidden {
+		t.Errorf("POST /pause = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := do(t, server, http.MethodGet, "/status", "", "", nil); code != http.StatusOK {
+		t.Errorf("GET /status = %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,280 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/types"
+)
+
+// idlePollInterval keeps the bot's own polling loop out of the way; the
+// backtest checks orders itself after every simulated price move
+const idlePollInterval = 24 * time.Hour
+
+// Config configures a backtest
+type Config struct {
+	Bot         bot.GridBotConfig // Bot.Fees are also charged by the simulated exchange
+	Symbol      types.SymbolInfo  // Trading rules; base and quote assets default to a split of the symbol name
+	InitialBase float64           // Base asset held at the start; the investment is held in quote
+	Logger      *slog.Logger      // Receives the bot's logs, nil for the default logger
+}
+
+// Report summarizes a backtest
+type Report struct {
+	Symbol          string    `json:"symbol"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Candles         int       `json:"candles"`
+	Fills           int       `json:"fills"` // Grid order fills
+	Buys            int       `json:"buys"`
+	Sells           int       `json:"sells"`
+	Volume          float64   `json:"volume"`          // Quote value traded, including the initial base purchase
+	Fees            float64   `json:"fees"`            // In quote currency
+	RealizedPnL     float64   `json:"realizedPnl"`     // Grid profit from completed round trips, before fees
+	UnrealizedPnL   float64   `json:"unrealizedPnl"`   // Mark-to-market result of the inventory held
+	NetPnL          float64   `json:"netPnl"`          // Change in equity, after fees
+	InitialEquity   float64   `json:"initialEquity"`   // In quote currency
+	FinalEquity     float64   `json:"finalEquity"`     // In quote currency, at the last close
+	Return          float64   `json:"return"`          // NetPnL as a fraction of InitialEquity
+	MaxDrawdown     float64   `json:"maxDrawdown"`     // Largest peak-to-trough equity decline, as a fraction of the peak
+	Sharpe          float64   `json:"sharpe"`          // Annualized Sharpe ratio of the per-candle equity returns, zero risk-free rate
+	GridUtilization float64   `json:"gridUtilization"` // Share of grid levels, including those added by trailing, with at least one fill
+	TimeInRange     float64   `json:"timeInRange"`     // Share of candles that closed inside the grid
+
+	StopReason bot.StopReason `json:"stopReason,omitempty"` // Stop-loss or take-profit that ended the backtest at End, if any
+}
+
+// Run replays candles through a simulated exchange running the grid bot and
+// reports the result. The bot buys the base asset its sell orders need at the
+// first candle's open. A stop-loss or take-profit ends the backtest with the
+// candle it fired in.
+func Run(ctx context.Context, config Config, candles []Candle) (*Report, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles to backtest")
+	}
+
+	info := config.Symbol
+	if info.Symbol == "" {
+		info.Symbol = config.Bot.Symbol
+	}
+	if info.BaseAsset == "" || info.QuoteAsset == "" {
+		base, quote, ok := bot.SplitSymbol(info.Symbol)
+		if !ok {
+			return nil, fmt.Errorf("cannot determine the base and quote assets of %s", info.Symbol)
+		}
+		info.BaseAsset, info.QuoteAsset = base, quote
+	}
+
+	sim, err := exchange.NewSimulatedExchange(exchange.SimulatorConfig{
+		Symbol: info,
+		Balances: map[string]float64{
+			info.QuoteAsset: config.Bot.Investment,
+			info.BaseAsset:  config.InitialBase,
+		},
+		MakerFee: config.Bot.Fees.Maker(),
+		TakerFee: config.Bot.Fees.Taker(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create simulated exchange: %w", err)
+	}
+
+	first := candles[0]
+	sim.SetPrice(first.OpenTime, first.Open)
+	equity := func(price float64) float64 {
+		return sim.Holdings(info.QuoteAsset) + sim.Holdings(info.BaseAsset)*price
+	}
+
+	report := &Report{
+		Symbol:        info.Symbol,
+		Start:         first.OpenTime,
+		InitialEquity: equity(first.Open),
+	}
+
+	botConfig := config.Bot
+	botConfig.AcquireBase = true
+	botConfig.PollInterval = idlePollInterval
+
+	var opts []bot.Option
+	if config.Logger != nil {
+		opts = append(opts, bot.WithLogger(config.Logger))
+	}
+	gridBot, err := bot.NewGridBot(sim, botConfig, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := gridBot.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	// Levels the grid has had, which trailing adds to
+	gridLevels := make(map[float64]bool)
+	trackLevels := func() (lower, upper float64) {
+		levels := gridBot.GetStatus().Levels
+		for _, level := range levels {
+			gridLevels[level.Price] = true
+		}
+		return levels[0].Price, levels[len(levels)-1].Price
+	}
+	trackLevels()
+
+	peak := report.InitialEquity
+	inRange := 0
+	equities := make([]float64, 0, len(candles)+1)
+	equities = append(equities, report.InitialEquity)
+	last := len(candles) - 1
+	for i, candle := range candles {
+		for j, price := range pricePath(candle) {
+			if i == 0 && j == 0 {
+				continue // The bot started at the first open
+			}
+			if ctx.Err() != nil {
+				gridBot.Stop(context.Background())
+				return nil, ctx.Err()
+			}
+			sim.SetPrice(candle.OpenTime, price)
+			gridBot.CheckOrders(ctx)
+			gridBot.UpdatePrice(ctx, price)
+			if report.StopReason = gridBot.GetStatus().StopReason; report.StopReason != "" {
+				break
+			}
+		}
+		lower, upper := trackLevels()
+
+		value := equity(candle.Close)
+		equities = append(equities, value)
+		if value > peak {
+			peak = value
+		}
+		if drawdown := (peak - value) / peak; drawdown > report.MaxDrawdown {
+			report.MaxDrawdown = drawdown
+		}
+		if candle.Close >= lower && candle.Close <= upper {
+			inRange++
+		}
+		if report.StopReason != "" {
+			last = i
+			break
+		}
+	}
+	report.End = candles[last].OpenTime
+	report.Candles = last + 1
+
+	status := gridBot.GetStatus()
+	report.RealizedPnL = status.PnL.RealizedPnL
+
+	report.FinalEquity = equity(candles[last].Close)
+	report.NetPnL = report.FinalEquity - report.InitialEquity
+	report.Fees = sim.Fees()
+	report.UnrealizedPnL = report.NetPnL - report.RealizedPnL + report.Fees
+	if report.InitialEquity > 0 {
+		report.Return = report.NetPnL / report.InitialEquity
+	}
+	report.TimeInRange = float64(inRange) / float64(report.Candles)
+	report.Sharpe = sharpeRatio(equities, candleInterval(candles))
+
+	levels := make(map[float64]bool)
+	for _, fill := range sim.Fills() {
+		report.Volume += fill.LastPrice * fill.LastQuantity
+		if fill.ClientOrderID == "" {
+			continue // Initial purchase of base asset
+		}
+		report.Fills++
+		if fill.Side == "BUY" {
+			report.Buys++
+		} else {
+			report.Sells++
+		}
+		levels[fill.Price] = true
+	}
+	report.GridUtilization = float64(len(levels)) / float64(len(gridLevels))
+
+	if report.StopReason != "" {
+		if err := gridBot.GetStatus().Error; err != "" {
+			return nil, fmt.Errorf("grid bot failed to stop on %s: %s", report.StopReason, err)
+		}
+		return report, nil
+	}
+	if err := gridBot.Stop(ctx); err != nil {
+		return nil, fmt.Errorf("failed to stop grid bot: %w", err)
+	}
+	return report, nil
+}
+
+// sharpeRatio returns the Sharpe ratio of the returns between consecutive
+// equity values, annualized assuming they are interval apart. It is zero when
+// the returns do not vary.
+func sharpeRatio(equities []float64, interval time.Duration) float64 {
+	if len(equities) < 3 || interval <= 0 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(equities)-1)
+	var mean float64
+	for i := 1; i < len(equities); i++ {
+		r := equities[i]/equities[i-1] - 1
+		returns = append(returns, r)
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(returns)-1))
+	if stddev == 0 {
+		return 0
+	}
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+	return mean / stddev * math.Sqrt(periodsPerYear)
+}
+
+// candleInterval returns the time between the first two candles
+func candleInterval(candles []Candle) time.Duration {
+	if len(candles) < 2 {
+		return 0
+	}
+	return candles[1].OpenTime.Sub(candles[0].OpenTime)
+}
+
+// pricePath returns the order in which a candle is assumed to have visited
+// its prices: a rising candle dips to its low before reaching its high, a
+// falling candle does the opposite
+func pricePath(c Candle) []float64 {
+	if c.Close >= c.Open {
+		return []float64{c.Open, c.Low, c.High, c.Close}
+	}
+	return []float64{c.Open, c.High, c.Low, c.Close}
+}
+
+// WriteText writes a human readable summary of the report
+func (r *Report) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `Backtest %s from %s to %s (%d candles)
+Fills:            %d (%d buys, %d sells)
+Volume:           %.2f
+Fees:             %.2f
+Realized PnL:     %.2f
+Unrealized PnL:   %.2f
+Net PnL:          %.2f (%.2f%%)
+Equity:           %.2f -> %.2f
+Max drawdown:     %.2f%%
+Sharpe ratio:     %.2f
+Grid utilization: %.2f%%
+Time in range:    %.2f%%
+`,
+		r.Symbol, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.Candles,
+		r.Fills, r.Buys, r.Sells, r.Volume, r.Fees, r.RealizedPnL, r.UnrealizedPnL,
+		r.NetPnL, r.Return*100, r.InitialEquity, r.FinalEquity,
+		r.MaxDrawdown*100, r.Sharpe, r.GridUtilization*100, r.TimeInRange*100)
+	if err == nil && r.StopReason != "" {
+		_, err = fmt.Fprintf(w, "Stopped by:       %s\n", r.StopReason)
+	}
+	return err
+}
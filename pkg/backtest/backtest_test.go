@@ -0,0 +1,187 @@
+package backtest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+	"spot_grid_bot/pkg/grid"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil))) // The bot logs every order
+	os.Exit(m.Run())
+}
+
+func candle(minute int, open, high, low, close float64) Candle {
+	return Candle{
+		OpenTime: time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC),
+		Open:     open,
+		High:     high,
+		Low:      low,
+		Close:    close,
+	}
+}
+
+func TestRun(t *testing.T) {
+	config := Config{
+		Bot: bot.GridBotConfig{
+			Symbol:     "BTCUSDT",
+			LowerPrice: 25000.0,
+			UpperPrice: 35000.0,
+			GridNum:    5,
+			Investment: 1000.0,
+			Fees:       grid.Fees{MakerRate: grid.DefaultFeeRate, TakerRate: grid.DefaultFeeRate},
+		},
+	}
+	candles := []Candle{
+		// Dips through the 27500 buy, then rallies through the 30000 counter-sell
+		candle(0, 30000, 30100, 27400, 30100),
+		candle(1, 30100, 30200, 29000, 29000),
+		// Falls out of the range
+		candle(2, 29000, 29000, 24000, 24000),
+	}
+
+	report, err := Run(context.Background(), config, candles)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Candles != 3 || report.Fills != 4 || report.Buys != 3 || report.Sells != 1 {
+		t.Errorf("Got %d candles, %d fills (%d buys, %d sells), want 3 candles, 4 fills (3 buys, 1 sell)",
+			report.Candles, report.Fills, report.Buys, report.Sells)
+	}
+	// The counter-sell carries the quantity bought less its commission
+	if want := (30000.0 - 27500.0) * (200.0 / 27500.0) * (1 - grid.DefaultFeeRate); math.Abs(report.RealizedPnL-want) > 1e-9 {
+		t.Errorf("RealizedPnL = %v, want %v", report.RealizedPnL, want)
+	}
+	if report.Fees <= 0 {
+		t.Errorf("Expected fees to be charged, got %v", report.Fees)
+	}
+	if got := report.RealizedPnL + report.UnrealizedPnL - report.Fees; math.Abs(got-report.NetPnL) > 1e-9 {
+		t.Errorf("Realized + unrealized - fees = %v, want net PnL %v", got, report.NetPnL)
+	}
+	if report.NetPnL >= 0 || report.MaxDrawdown <= 0 {
+		t.Errorf("Expected a loss and a drawdown after falling out of the range, got net %v, drawdown %v",
+			report.NetPnL, report.MaxDrawdown)
+	}
+	// Fills at 25000, 27500 and 30000 out of 5 levels
+	if report.GridUtilization != 0.6 {
+		t.Errorf("GridUtilization = %v, want 0.6", report.GridUtilization)
+	}
+	if want := 2.0 / 3.0; report.TimeInRange != want {
+		t.Errorf("TimeInRange = %v, want %v", report.TimeInRange, want)
+	}
+}
+
+func TestRunTrailing(t *testing.T) {
+	candles := []Candle{
+		// Rallies through the sells at 32500 and 35000 and out of the range
+		candle(0, 30000, 33000, 30000, 33000),
+		candle(1, 33000, 36000, 33000, 36000),
+		// Dips through 35000, then rallies through 37500
+		candle(2, 36000, 36000, 34900, 35500),
+		candle(3, 35500, 37600, 35500, 37600),
+	}
+
+	tests := []struct {
+		name            string
+		trailing        bot.TrailMode
+		wantBuys        int
+		wantSells       int
+		wantTimeInRange float64
+		wantUtilization float64
+	}{
+		{name: "Off", trailing: bot.TrailOff, wantSells: 2, wantTimeInRange: 0.25, wantUtilization: 2.0 / 5.0},
+		// The grid moves up to 27500-37500 and then 30000-40000, keeping a buy
+		// at 35000 that is sold at 37500
+		{name: "Up", trailing: bot.TrailUp, wantBuys: 1, wantSells: 3, wantTimeInRange: 1, wantUtilization: 3.0 / 7.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				Bot: bot.GridBotConfig{
+					Symbol:     "BTCUSDT",
+					LowerPrice: 25000.0,
+					UpperPrice: 35000.0,
+					GridNum:    5,
+					Investment: 1000.0,
+					Trailing:   tt.trailing,
+				},
+			}
+
+			report, err := Run(context.Background(), config, candles)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if report.Buys != tt.wantBuys || report.Sells != tt.wantSells {
+				t.Errorf("Got %d buys and %d sells, want %d and %d", report.Buys, report.Sells, tt.wantBuys, tt.wantSells)
+			}
+			if report.TimeInRange != tt.wantTimeInRange {
+				t.Errorf("TimeInRange = %v, want %v", report.TimeInRange, tt.wantTimeInRange)
+			}
+			if report.GridUtilization != tt.wantUtilization {
+				t.Errorf("GridUtilization = %v, want %v", report.GridUtilization, tt.wantUtilization)
+			}
+		})
+	}
+}
+
+func TestRunStopLoss(t *testing.T) {
+	config := Config{
+		Bot: bot.GridBotConfig{
+			Symbol:         "BTCUSDT",
+			LowerPrice:     25000.0,
+			UpperPrice:     35000.0,
+			GridNum:        5,
+			Investment:     1000.0,
+			StopLossPrice:  24500.0,
+			StopLossAction: bot.StopActionSell,
+		},
+	}
+	candles := []Candle{
+		candle(0, 30000, 30100, 29900, 30000),
+		// Falls through the stop-loss
+		candle(1, 30000, 30000, 24000, 24000),
+		// Not traded after the stop
+		candle(2, 24000, 26000, 24000, 26000),
+	}
+
+	report, err := Run(context.Background(), config, candles)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.StopReason != bot.StopReasonStopLoss {
+		t.Errorf("StopReason = %q, want %q", report.StopReason, bot.StopReasonStopLoss)
+	}
+	if report.Candles != 2 || !report.End.Equal(candles[1].OpenTime) {
+		t.Errorf("Got %d candles ending %v, want 2 ending %v", report.Candles, report.End, candles[1].OpenTime)
+	}
+	if report.TimeInRange != 0.5 {
+		t.Errorf("TimeInRange = %v, want 0.5", report.TimeInRange)
+	}
+}
+
+func TestRunRejectsInvalidConfig(t *testing.T) {
+	config := Config{
+		Bot: bot.GridBotConfig{
+			Symbol:     "BTCUSDT",
+			LowerPrice: 35000.0,
+			UpperPrice: 25000.0,
+			GridNum:    5,
+			Investment: 1000.0,
+		},
+	}
+	if _, err := Run(context.Background(), config, []Candle{candle(0, 30000, 30000, 30000, 30000)}); err == nil {
+		t.Error("Expected an invalid grid to fail")
+	}
+	if _, err := Run(context.Background(), Config{Bot: bot.GridBotConfig{Symbol: "BTCUSDT"}}, nil); err == nil {
+		t.Error("Expected no candles to fail")
+	}
+}
@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Candle is one OHLCV bar
+type Candle struct {
+	OpenTime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+}
+
+// LoadCandles reads candles from a file. Files ending in .json are parsed as
+// Binance kline JSON, anything else as CSV.
+func LoadCandles(path string) ([]Candle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open candle file: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ReadKlineJSON(file)
+	}
+	return ReadCSV(file)
+}
+
+// ReadCSV parses candles from CSV with the columns open time, open, high,
+// low, close and volume; further columns such as those in Binance's kline
+// dumps are ignored. The open time is Unix milliseconds (or microseconds) or
+// RFC 3339. A header row is skipped.
+func ReadCSV(r io.Reader) ([]Candle, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var candles []Candle
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(record) < 6 {
+			return nil, fmt.Errorf("line %d: expected at least 6 columns, got %d", line, len(record))
+		}
+		if line == 1 && !isNumeric(record[1]) {
+			continue // Header
+		}
+
+		candle, err := parseCandle(record[:6])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		candles = append(candles, candle)
+	}
+	return sortCandles(candles)
+}
+
+// ReadKlineJSON parses candles from the JSON returned by Binance's klines
+// endpoint: an array of arrays starting with open time, open, high, low,
+// close and volume.
+func ReadKlineJSON(r io.Reader) ([]Candle, error) {
+	var klines [][]interface{}
+	if err := json.NewDecoder(r).Decode(&klines); err != nil {
+		return nil, fmt.Errorf("failed to decode klines: %w", err)
+	}
+
+	candles := make([]Candle, 0, len(klines))
+	for i, kline := range klines {
+		if len(kline) < 6 {
+			return nil, fmt.Errorf("kline %d: expected at least 6 fields, got %d", i, len(kline))
+		}
+		fields := make([]string, 6)
+		for j, value := range kline[:6] {
+			switch v := value.(type) {
+			case string:
+				fields[j] = v
+			case float64:
+				fields[j] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return nil, fmt.Errorf("kline %d: unexpected value %v", i, value)
+			}
+		}
+
+		candle, err := parseCandle(fields)
+		if err != nil {
+			return nil, fmt.Errorf("kline %d: %w", i, err)
+		}
+		candles = append(candles, candle)
+	}
+	return sortCandles(candles)
+}
+
+// parseCandle parses open time, open, high, low, close and volume
+func parseCandle(fields []string) (Candle, error) {
+	openTime, err := parseTime(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return Candle{}, err
+	}
+
+	values := make([]float64, 5)
+	for i, field := range fields[1:6] {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return Candle{}, fmt.Errorf("invalid number %q: %w", field, err)
+		}
+	}
+
+	candle := Candle{
+		OpenTime: openTime,
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+		Volume:   values[4],
+	}
+	if candle.Low <= 0 || candle.High < candle.Low ||
+		candle.Open < candle.Low || candle.Open > candle.High ||
+		candle.Close < candle.Low || candle.Close > candle.High {
+		return Candle{}, fmt.Errorf("inconsistent candle at %s: open %v, high %v, low %v, close %v",
+			openTime.Format(time.RFC3339), candle.Open, candle.High, candle.Low, candle.Close)
+	}
+	return candle, nil
+}
+
+// parseTime parses a Unix timestamp in milliseconds or microseconds, or an
+// RFC 3339 time
+func parseTime(value string) (time.Time, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Binance switched its spot dumps to microseconds in 2025
+		if ts > 1e14 {
+			return time.UnixMicro(ts).UTC(), nil
+		}
+		return time.UnixMilli(ts).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid open time %q", value)
+	}
+	return t, nil
+}
+
+// sortCandles orders candles by open time and rejects empty input
+func sortCandles(candles []Candle) ([]Candle, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles found")
+	}
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].OpenTime.Before(candles[j].OpenTime)
+	})
+	return candles, nil
+}
+
+// isNumeric reports whether value parses as a number
+func isNumeric(value string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil
+}
@@ -0,0 +1,93 @@
+package backtest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantTime []time.Time
+		wantErr  bool
+	}{
+		{
+			name: "Header and millisecond times",
+			input: "open_time,open,high,low,close,volume\n" +
+				"1700000060000,101,102,100,101.5,10\n" +
+				"1700000000000,100,101,99,101,12\n",
+			wantTime: []time.Time{time.UnixMilli(1700000000000), time.UnixMilli(1700000060000)},
+		},
+		{
+			name:     "Binance dump with microsecond times",
+			input:    "1735689600000000,100,101,99,100.5,5,1735689659999999,502.5,12,2,201,0\n",
+			wantTime: []time.Time{time.UnixMicro(1735689600000000)},
+		},
+		{
+			name:     "RFC 3339 times",
+			input:    "2024-01-01T00:00:00Z,100,101,99,100.5,5\n",
+			wantTime: []time.Time{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:    "Too few columns",
+			input:   "1700000000000,100,101,99,101\n",
+			wantErr: true,
+		},
+		{
+			name:    "Close above high",
+			input:   "1700000000000,100,101,99,102,12\n",
+			wantErr: true,
+		},
+		{
+			name:    "Empty",
+			input:   "open_time,open,high,low,close,volume\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles, err := ReadCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(candles) != len(tt.wantTime) {
+				t.Fatalf("Got %d candles, want %d", len(candles), len(tt.wantTime))
+			}
+			for i, want := range tt.wantTime {
+				if !candles[i].OpenTime.Equal(want) {
+					t.Errorf("Candle %d open time = %v, want %v", i, candles[i].OpenTime, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadKlineJSON(t *testing.T) {
+	input := `[
+		[1499040000000, "0.01634790", "0.80000000", "0.01575800", "0.01577100", "148976.11427815",
+		 1499644799999, "2434.19055334", 308, "1756.87402397", "28.46694368", "0"]
+	]`
+
+	candles, err := ReadKlineJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadKlineJSON() error = %v", err)
+	}
+	want := Candle{
+		OpenTime: time.UnixMilli(1499040000000).UTC(),
+		Open:     0.0163479,
+		High:     0.8,
+		Low:      0.015758,
+		Close:    0.015771,
+		Volume:   148976.11427815,
+	}
+	if len(candles) != 1 || candles[0] != want {
+		t.Errorf("ReadKlineJSON() = %+v, want [%+v]", candles, want)
+	}
+
+	if _, err := ReadKlineJSON(strings.NewReader(`[[1499040000000, "1"]]`)); err == nil {
+		t.Error("Expected short kline to fail")
+	}
+}
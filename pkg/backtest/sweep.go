@@ -0,0 +1,205 @@
+package backtest
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+
+	"spot_grid_bot/pkg/grid"
+)
+
+// Rank is a metric sweep results are ordered by
+type Rank string
+
+const (
+	RankReturn   Rank = "return"   // Highest return first
+	RankSharpe   Rank = "sharpe"   // Highest Sharpe ratio first
+	RankDrawdown Rank = "drawdown" // Smallest max drawdown first
+)
+
+// ParseRank converts a metric name into a Rank
+func ParseRank(name string) (Rank, error) {
+	switch rank := Rank(name); rank {
+	case RankReturn, RankSharpe, RankDrawdown:
+		return rank, nil
+	}
+	return "", fmt.Errorf("unknown rank metric %q, expected %q, %q or %q",
+		name, RankReturn, RankSharpe, RankDrawdown)
+}
+
+// SweepConfig describes the grid configurations to backtest. Every
+// combination of the listed values is run with the rest of Base unchanged.
+type SweepConfig struct {
+	Base        Config
+	LowerPrices []float64
+	UpperPrices []float64
+	GridNums    []int
+	Spacings    []grid.Spacing
+	Sizings     []grid.Sizing
+	Workers     int // Backtests run in parallel (default: number of CPUs)
+}
+
+// SweepResult is the outcome of one configuration in a sweep
+type SweepResult struct {
+	LowerPrice float64      `json:"lowerPrice"`
+	UpperPrice float64      `json:"upperPrice"`
+	GridNum    int          `json:"gridNum"`
+	Spacing    grid.Spacing `json:"spacing"`
+	Sizing     grid.Sizing  `json:"sizing"`
+	Report     *Report      `json:"report,omitempty"`
+	Error      string       `json:"error,omitempty"` // Why the configuration could not be run
+}
+
+// Sweep backtests every combination in config against candles in parallel
+// and returns the results ranked by rank. Combinations with the lower bound
+// at or above the upper bound are skipped; combinations the bot rejects are
+// kept with their error and ranked last.
+func Sweep(ctx context.Context, config SweepConfig, candles []Candle, rank Rank) ([]SweepResult, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles to backtest")
+	}
+	for _, spacing := range config.Spacings {
+		if spacing == grid.SpacingExplicit {
+			return nil, fmt.Errorf("explicit spacing cannot be swept")
+		}
+	}
+
+	combos := combinations(config)
+	if len(combos) == 0 {
+		return nil, fmt.Errorf("no valid grid configurations to sweep")
+	}
+
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				combos[i].run(ctx, config.Base, candles)
+			}
+		}()
+	}
+	for i := range combos {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	RankResults(combos, rank)
+	return combos, nil
+}
+
+// combinations lists every valid combination of the swept parameters
+func combinations(config SweepConfig) []SweepResult {
+	var combos []SweepResult
+	for _, lower := range config.LowerPrices {
+		for _, upper := range config.UpperPrices {
+			if lower >= upper {
+				continue
+			}
+			for _, gridNum := range config.GridNums {
+				for _, spacing := range config.Spacings {
+					for _, sizing := range config.Sizings {
+						combos = append(combos, SweepResult{
+							LowerPrice: lower,
+							UpperPrice: upper,
+							GridNum:    gridNum,
+							Spacing:    spacing,
+							Sizing:     sizing,
+						})
+					}
+				}
+			}
+		}
+	}
+	return combos
+}
+
+// run backtests the configuration and records the report or error
+func (r *SweepResult) run(ctx context.Context, base Config, candles []Candle) {
+	config := base
+	config.Bot.LowerPrice = r.LowerPrice
+	config.Bot.UpperPrice = r.UpperPrice
+	config.Bot.GridNum = r.GridNum
+	config.Bot.Spacing = r.Spacing
+	config.Bot.Sizing = r.Sizing
+
+	report, err := Run(ctx, config, candles)
+	if err != nil {
+		r.Error = err.Error()
+		return
+	}
+	r.Report = report
+}
+
+// RankResults orders results by rank, best first. Failed results go last.
+func RankResults(results []SweepResult, rank Rank) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Report, results[j].Report
+		if a == nil || b == nil {
+			return a != nil
+		}
+		switch rank {
+		case RankSharpe:
+			return a.Sharpe > b.Sharpe
+		case RankDrawdown:
+			return a.MaxDrawdown < b.MaxDrawdown
+		default:
+			return a.Return > b.Return
+		}
+	})
+}
+
+// WriteSweepCSV writes results as CSV with a header row
+func WriteSweepCSV(w io.Writer, results []SweepResult) error {
+	writer := csv.NewWriter(w)
+	header := []string{"rank", "lower", "upper", "grids", "spacing", "sizing",
+		"return", "sharpe", "max_drawdown", "net_pnl", "realized_pnl", "fees",
+		"fills", "grid_utilization", "time_in_range", "error"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for i, result := range results {
+		record := []string{strconv.Itoa(i + 1), format(result.LowerPrice), format(result.UpperPrice),
+			strconv.Itoa(result.GridNum), string(result.Spacing), string(result.Sizing)}
+		if report := result.Report; report != nil {
+			record = append(record, format(report.Return), format(report.Sharpe), format(report.MaxDrawdown),
+				format(report.NetPnL), format(report.RealizedPnL), format(report.Fees),
+				strconv.Itoa(report.Fills), format(report.GridUtilization), format(report.TimeInRange), "")
+		} else {
+			record = append(record, "", "", "", "", "", "", "", "", "", result.Error)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteSweepJSON writes results as an indented JSON array
+func WriteSweepJSON(w io.Writer, results []SweepResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
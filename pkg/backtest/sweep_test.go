@@ -0,0 +1,121 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+	"spot_grid_bot/pkg/grid"
+)
+
+func TestSweep(t *testing.T) {
+	config := SweepConfig{
+		Base: Config{
+			Bot: bot.GridBotConfig{
+				Symbol:     "BTCUSDT",
+				Investment: 1000.0,
+				Fees:       grid.Fees{MakerRate: grid.DefaultFeeRate, TakerRate: grid.DefaultFeeRate},
+			},
+		},
+		LowerPrices: []float64{25000, 28000, 36000},
+		UpperPrices: []float64{32000, 35000},
+		GridNums:    []int{3, 5},
+		Spacings:    []grid.Spacing{grid.SpacingArithmetic, grid.SpacingGeometric},
+		Sizings:     []grid.Sizing{grid.SizingEqualQuote},
+		Workers:     3,
+	}
+	// Oscillates between 28500 and 31500
+	var candles []Candle
+	for i := 0; i < 6; i++ {
+		candles = append(candles, candle(2*i, 30000, 31500, 28500, 30500), candle(2*i+1, 30500, 31500, 28500, 30000))
+	}
+
+	results, err := Sweep(context.Background(), config, candles, RankReturn)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+
+	// Lower bounds of 36000 exceed every upper bound and are skipped
+	if len(results) != 16 {
+		t.Fatalf("Expected 16 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if result.Report == nil {
+			t.Fatalf("Result %d failed: %s", i, result.Error)
+		}
+		if i > 0 && result.Report.Return > results[i-1].Report.Return {
+			t.Errorf("Result %d has a higher return than result %d", i, i-1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSweepCSV(&buf, results); err != nil {
+		t.Fatalf("WriteSweepCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(records) != 17 || records[1][0] != "1" {
+		t.Errorf("Expected a header and 16 ranked rows, got %v", records)
+	}
+}
+
+func TestSweepRejectsExplicitSpacing(t *testing.T) {
+	config := SweepConfig{
+		Base:        Config{Bot: bot.GridBotConfig{Symbol: "BTCUSDT", Investment: 1000.0}},
+		LowerPrices: []float64{25000},
+		UpperPrices: []float64{35000},
+		GridNums:    []int{5},
+		Spacings:    []grid.Spacing{grid.SpacingExplicit},
+		Sizings:     []grid.Sizing{grid.SizingEqualQuote},
+	}
+	if _, err := Sweep(context.Background(), config, []Candle{candle(0, 30000, 30000, 30000, 30000)}, RankReturn); err == nil {
+		t.Error("Expected explicit spacing to be rejected")
+	}
+}
+
+func TestRankResults(t *testing.T) {
+	results := []SweepResult{
+		{GridNum: 1, Report: &Report{Return: 0.1, Sharpe: 2, MaxDrawdown: 0.2}},
+		{GridNum: 2, Error: "failed"},
+		{GridNum: 3, Report: &Report{Return: 0.2, Sharpe: 1, MaxDrawdown: 0.1}},
+		{GridNum: 4, Report: &Report{Return: 0.0, Sharpe: 3, MaxDrawdown: 0.3}},
+	}
+
+	tests := []struct {
+		rank Rank
+		want []int
+	}{
+		{rank: RankReturn, want: []int{3, 1, 4, 2}},
+		{rank: RankSharpe, want: []int{4, 1, 3, 2}},
+		{rank: RankDrawdown, want: []int{3, 1, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.rank), func(t *testing.T) {
+			ranked := append([]SweepResult(nil), results...)
+			RankResults(ranked, tt.rank)
+			for i, result := range ranked {
+				if result.GridNum != tt.want[i] {
+					t.Fatalf("Ranked order %v, want %v", ranked, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSharpeRatio(t *testing.T) {
+	if got := sharpeRatio([]float64{100, 100, 100}, time.Hour); got != 0 {
+		t.Errorf("Expected 0 for flat equity, got %v", got)
+	}
+	// Returns of +10% and -5%: mean 2.5%, sample stddev 10.61%
+	got := sharpeRatio([]float64{100, 110, 104.5}, 365*24*time.Hour)
+	if want := 0.025 / (0.15 / math.Sqrt2); math.Abs(got-want) > 1e-9 {
+		t.Errorf("sharpeRatio() = %v, want %v", got, want)
+	}
+}
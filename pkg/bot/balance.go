@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+)
+
+// baseAcquisitionBuffer is the extra share of base asset bought on top of the
+// shortfall, covering trading fees deducted from the purchased amount
+const baseAcquisitionBuffer = 0.002
+
+// knownQuoteAssets are the quote assets SplitSymbol recognizes
+var knownQuoteAssets = []string{"USDT", "FDUSD", "USDC", "BUSD", "TUSD", "BTC", "ETH", "BNB"}
+
+// BalanceShortfall describes an asset the account holds too little of
+type BalanceShortfall struct {
+	Asset     string
+	Side      string // Side of the orders that need the asset
+	Orders    int    // Number of orders that need the asset
+	Required  float64
+	Available float64
+}
+
+// InsufficientBalanceError is returned by Start when the account cannot fund
+// every order of the grid
+type InsufficientBalanceError struct {
+	Shortfalls []BalanceShortfall
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	parts := make([]string, len(e.Shortfalls))
+	for i, s := range e.Shortfalls {
+		parts[i] = fmt.Sprintf("%d %s orders need %.8f %s, have %.8f (short %.8f)",
+			s.Orders, s.Side, s.Required, s.Asset, s.Available, s.Required-s.Available)
+	}
+	return "insufficient balance for grid orders: " + strings.Join(parts, "; ")
+}
+
+// ensureBalances checks that the account holds enough quote asset for the
+// planned buy orders and enough base asset for the planned sell orders. With
+// AcquireBase set, missing base asset is bought at market first.
+func (b *GridBot) ensureBalances(ctx context.Context, planned []plannedOrder, currentPrice float64) error {
+	if len(planned) == 0 {
+		return nil
+	}
+	base, quote, ok := b.assets()
+	if !ok {
+		b.logger.Warn("Cannot determine the symbol's assets, skipping balance check")
+		return nil
+	}
+
+	var needQuote, needBase float64
+	var buys, sells int
+	for _, order := range planned {
+		if order.side == "BUY" {
+			needQuote += order.quantity * b.levels[order.level]
+			buys++
+		} else {
+			needBase += order.quantity
+			sells++
+		}
+	}
+
+	haveQuote, haveBase, err := b.balances(ctx, base, quote)
+	if err != nil {
+		return err
+	}
+
+	if haveBase < needBase && b.config.AcquireBase {
+		quantity := grid.CeilToStep((needBase-haveBase)*(1+baseAcquisitionBuffer), b.symbolInfo.StepSize)
+		if cost := quantity * currentPrice; haveQuote < needQuote+cost {
+			return &InsufficientBalanceError{Shortfalls: []BalanceShortfall{{
+				Asset:     quote,
+				Side:      "BUY",
+				Orders:    buys + 1, // Including the market buy of base asset
+				Required:  needQuote + cost,
+				Available: haveQuote,
+			}}}
+		}
+
+		if err := b.acquireBase(ctx, quantity, currentPrice); err != nil {
+			return err
+		}
+		if haveQuote, haveBase, err = b.balances(ctx, base, quote); err != nil {
+			return err
+		}
+	}
+
+	var shortfalls []BalanceShortfall
+	if haveQuote < needQuote {
+		shortfalls = append(shortfalls, BalanceShortfall{
+			Asset: quote, Side: "BUY", Orders: buys, Required: needQuote, Available: haveQuote,
+		})
+	}
+	if haveBase < needBase {
+		shortfalls = append(shortfalls, BalanceShortfall{
+			Asset: base, Side: "SELL", Orders: sells, Required: needBase, Available: haveBase,
+		})
+	}
+	if len(shortfalls) > 0 {
+		return &InsufficientBalanceError{Shortfalls: shortfalls}
+	}
+	return nil
+}
+
+// acquireBase buys quantity of the base asset at market, expected to fill
+// around price, and books the purchase in the ledger
+func (b *GridBot) acquireBase(ctx context.Context, quantity, price float64) error {
+	order := types.Order{
+		Symbol:   b.config.Symbol,
+		Side:     "BUY",
+		Type:     "MARKET",
+		Quantity: quantity,
+		Price:    price,
+	}
+	orderID, err := b.exchange.PlaceOrder(ctx, order)
+	if err != nil {
+		return fmt.Errorf("failed to buy base asset for sell orders: %w", err)
+	}
+
+	// Book the execution if the exchange reports it, the order as placed
+	// otherwise
+	executed, fillPrice := quantity, price
+	if info, err := b.exchange.GetOrder(ctx, b.config.Symbol, orderID); err != nil {
+		b.logger.Warn("Failed to get market buy, booking it at the expected price", "orderID", orderID, "error", err)
+	} else if info.ExecutedQuantity > 0 {
+		executed = info.ExecutedQuantity
+		if info.AveragePrice > 0 {
+			fillPrice = info.AveragePrice
+		}
+	}
+
+	// The purchase is not tracked as a grid order, so its fee is estimated
+	fee := executed * b.config.Fees.Taker()
+	b.mu.Lock()
+	b.ledger.addEstimatedFee(fee * fillPrice)
+	if b.config.Fees.BNBDiscount == 0 {
+		// Unless paid in BNB, the commission comes out of the base asset received
+		executed -= fee
+	}
+	b.ledger.open(executed, fillPrice)
+	b.mu.Unlock()
+
+	b.logger.Info("Bought base asset at market for sell orders", "orderID", orderID, "side", order.Side,
+		"price", fillPrice, "qty", executed)
+	return nil
+}
+
+// openInventory books the base asset locked in sell orders beyond the
+// ledger's inventory as opening inventory valued at price. It reports
+// whether anything was booked.
+func (b *GridBot) openInventory(price float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var selling float64
+	for orderID, order := range b.orders {
+		if order.order.Side == "SELL" {
+			selling += order.order.Quantity - b.filled[orderID]
+		}
+	}
+	quantity := selling - b.ledger.Inventory
+	if quantity <= dustQuantity {
+		return false
+	}
+	b.ledger.open(quantity, price)
+	return true
+}
+
+// balances returns the free quote and base balances
+func (b *GridBot) balances(ctx context.Context, base, quote string) (quoteBalance, baseBalance float64, err error) {
+	if quoteBalance, err = b.exchange.GetBalance(ctx, quote); err != nil {
+		return 0, 0, fmt.Errorf("failed to get %s balance: %w", quote, err)
+	}
+	if baseBalance, err = b.exchange.GetBalance(ctx, base); err != nil {
+		return 0, 0, fmt.Errorf("failed to get %s balance: %w", base, err)
+	}
+
+	b.mu.Lock()
+	b.lastBalances[quote] = quoteBalance
+	b.lastBalances[base] = baseBalance
+	b.balancesAt = time.Now()
+	b.mu.Unlock()
+	return quoteBalance, baseBalance, nil
+}
+
+// refreshBalances updates the balances reported in the status
+func (b *GridBot) refreshBalances(ctx context.Context) {
+	base, quote, ok := b.assets()
+	if !ok {
+		return
+	}
+	if _, _, err := b.balances(ctx, base, quote); err != nil {
+		b.logger.Warn("Failed to refresh balances", "error", err)
+	}
+}
+
+// assets returns the base and quote assets of the traded symbol
+func (b *GridBot) assets() (base, quote string, ok bool) {
+	if b.symbolInfo.BaseAsset != "" && b.symbolInfo.QuoteAsset != "" {
+		return b.symbolInfo.BaseAsset, b.symbolInfo.QuoteAsset, true
+	}
+	return SplitSymbol(b.config.Symbol)
+}
+
+// SplitSymbol splits a symbol such as BTCUSDT into its base and quote assets
+// by matching a list of common quote assets
+func SplitSymbol(symbol string) (base, quote string, ok bool) {
+	for _, quote := range knownQuoteAssets {
+		if base, found := strings.CutSuffix(symbol, quote); found && base != "" {
+			return base, quote, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+)
+
+// balanceExchange is a mockExchange with per-asset balances that fills
+// market orders immediately at the current price, or at marketPrice if set
+type balanceExchange struct {
+	*mockExchange
+	balances    map[string]float64
+	marketBuys  []float64 // Quantities of market buys
+	marketPrice float64
+}
+
+func (e *balanceExchange) GetBalance(ctx context.Context, asset string) (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.balances[asset], nil
+}
+
+func (e *balanceExchange) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	if order.Type != "MARKET" {
+		return e.mockExchange.PlaceOrder(ctx, order)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.marketBuys = append(e.marketBuys, order.Quantity)
+	e.balances["BTC"] += order.Quantity
+	e.balances["USDT"] -= order.Quantity * e.fillPrice()
+	return "market", nil
+}
+
+func (e *balanceExchange) GetOrder(ctx context.Context, symbol, orderID string) (types.OrderInfo, error) {
+	if orderID != "market" {
+		return e.mockExchange.GetOrder(ctx, symbol, orderID)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	quantity := e.marketBuys[len(e.marketBuys)-1]
+	return types.OrderInfo{
+		OrderID:          orderID,
+		Symbol:           symbol,
+		Side:             "BUY",
+		Quantity:         quantity,
+		ExecutedQuantity: quantity,
+		AveragePrice:     e.fillPrice(),
+		Status:           types.OrderStatusFilled,
+	}, nil
+}
+
+// fillPrice returns the price market orders fill at
+func (e *balanceExchange) fillPrice() float64 {
+	if e.marketPrice > 0 {
+		return e.marketPrice
+	}
+	return e.currentPrice
+}
+
+func TestGridBotBalanceCheck(t *testing.T) {
+	// At 30000 the grid places BUY orders worth 400 USDT at 25000 and 27500
+	// and SELL orders for about 0.01187 BTC at 32500 and 35000
+	tests := []struct {
+		name           string
+		balances       map[string]float64
+		acquireBase    bool
+		wantShortfalls []string // Assets reported short
+		wantMarketBuy  bool
+	}{
+		{
+			name:     "Sufficient balances",
+			balances: map[string]float64{"USDT": 400, "BTC": 0.012},
+		},
+		{
+			name:           "Short of both assets",
+			balances:       map[string]float64{"USDT": 399, "BTC": 0.01},
+			wantShortfalls: []string{"USDT", "BTC"},
+		},
+		{
+			name:          "Buys missing base asset",
+			balances:      map[string]float64{"USDT": 1000},
+			acquireBase:   true,
+			wantMarketBuy: true,
+		},
+		{
+			name:           "Not enough quote to buy base asset",
+			balances:       map[string]float64{"USDT": 500},
+			acquireBase:    true,
+			wantShortfalls: []string{"USDT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := &balanceExchange{
+				mockExchange: &mockExchange{
+					currentPrice: 30000.0,
+					orders:       make(map[string]mockOrder),
+				},
+				balances: tt.balances,
+			}
+			config := GridBotConfig{
+				Symbol:      "BTCUSDT",
+				LowerPrice:  25000.0,
+				UpperPrice:  35000.0,
+				GridNum:     5,
+				Investment:  1000.0,
+				AcquireBase: tt.acquireBase,
+			}
+
+			bot, err := NewGridBot(exchange, config)
+			if err != nil {
+				t.Fatalf("NewGridBot() error = %v", err)
+			}
+
+			err = bot.Start(context.Background())
+			if err == nil {
+				defer bot.Stop(context.Background())
+			}
+
+			var balanceErr *InsufficientBalanceError
+			if len(tt.wantShortfalls) > 0 {
+				if !errors.As(err, &balanceErr) {
+					t.Fatalf("Start() error = %v, want InsufficientBalanceError", err)
+				}
+				if len(balanceErr.Shortfalls) != len(tt.wantShortfalls) {
+					t.Fatalf("Got shortfalls %+v, want assets %v", balanceErr.Shortfalls, tt.wantShortfalls)
+				}
+				for i, asset := range tt.wantShortfalls {
+					if s := balanceErr.Shortfalls[i]; s.Asset != asset || s.Required <= s.Available {
+						t.Errorf("Shortfall %d = %+v, want %s short", i, s, asset)
+					}
+				}
+				if len(exchange.orders) != 0 {
+					t.Errorf("Expected no orders after a failed balance check, got %d", len(exchange.orders))
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Start() error = %v", err)
+				}
+				if len(exchange.orders) != 4 {
+					t.Errorf("Expected 4 grid orders, got %d", len(exchange.orders))
+				}
+			}
+
+			if got := len(exchange.marketBuys) > 0; got != tt.wantMarketBuy {
+				t.Errorf("Market buy placed = %v, want %v", got, tt.wantMarketBuy)
+			}
+		})
+	}
+}
+
+func TestGridBotBooksAcquiredBase(t *testing.T) {
+	exchange := &balanceExchange{
+		mockExchange: &mockExchange{
+			currentPrice: 30000.0,
+			orders:       make(map[string]mockOrder),
+		},
+		balances:    map[string]float64{"USDT": 1000},
+		marketPrice: 30010.0,
+	}
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+		AcquireBase:  true,
+		Fees:         grid.Fees{MakerRate: 0.001, TakerRate: 0.001},
+	}
+	store := &memStore{}
+
+	first, err := NewGridBot(exchange, config, WithStateStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := first.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+
+	// The purchase is the whole opening inventory, at its fill price and
+	// less the commission taken from it
+	if len(exchange.marketBuys) != 1 {
+		t.Fatalf("Expected 1 market buy, got %v", exchange.marketBuys)
+	}
+	bought := exchange.marketBuys[0] * (1 - 0.001)
+	pnl := first.PnL()
+	assertClose(t, "Inventory", pnl.Inventory, bought)
+	assertClose(t, "AverageCost", pnl.AverageCost, 30010)
+
+	// Simulate a crash, then lose the sell at 35000 and its base asset
+	cancel()
+	<-first.done
+	sell, ok := exchange.openOrder("SELL", 35000.0)
+	if !ok {
+		t.Fatal("Expected a sell at 35000")
+	}
+	exchange.CancelOrder(context.Background(), "BTCUSDT", sell.orderID)
+	exchange.balances["BTC"] = 0
+
+	second, err := NewGridBot(exchange, config, WithStateStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	if err := second.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to resume bot: %v", err)
+	}
+	defer second.Stop(context.Background())
+
+	// The resumed bot books the base asset it buys on top of the inventory
+	if len(exchange.marketBuys) != 2 {
+		t.Fatalf("Expected a second market buy on resume, got %v", exchange.marketBuys)
+	}
+	bought += exchange.marketBuys[1] * (1 - 0.001)
+	pnl = second.PnL()
+	assertClose(t, "Inventory after resume", pnl.Inventory, bought)
+	assertClose(t, "AverageCost after resume", pnl.AverageCost, 30010)
+}
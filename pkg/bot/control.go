@@ -0,0 +1,308 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrAlreadyRunning is returned when starting a bot that is running
+	ErrAlreadyRunning = errors.New("bot is already running")
+	// ErrNotRunning is returned when controlling a bot that is not running
+	ErrNotRunning = errors.New("bot is not running")
+	// ErrPaused is returned when pausing a bot that is paused
+	ErrPaused = errors.New("bot is paused")
+	// ErrNotPaused is returned when resuming a bot that is not paused
+	ErrNotPaused = errors.New("bot is not paused")
+)
+
+// ConfigError is returned by Reconfigure when the new configuration is
+// invalid. The bot is left unchanged.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// RunState is a step in the bot's lifecycle
+type RunState string
+
+const (
+	// RunStateIdle is a bot that has not been started
+	RunStateIdle RunState = "idle"
+	// RunStateStarting is a bot placing its grid
+	RunStateStarting RunState = "starting"
+	// RunStateRunning is a bot trading its grid
+	RunStateRunning RunState = "running"
+	// RunStatePaused is a started bot that handles no fills and places no
+	// orders until resumed
+	RunStatePaused RunState = "paused"
+	// RunStateStopping is a bot canceling its orders
+	RunStateStopping RunState = "stopping"
+	// RunStateStopped is a bot that stopped with its orders canceled
+	RunStateStopped RunState = "stopped"
+	// RunStateError is a bot that failed to start, or stopped without
+	// canceling all its orders
+	RunStateError RunState = "error"
+)
+
+// RunStates lists every RunState in lifecycle order
+var RunStates = []RunState{
+	RunStateIdle, RunStateStarting, RunStateRunning, RunStatePaused,
+	RunStateStopping, RunStateStopped, RunStateError,
+}
+
+// started reports whether the bot is between Start and the end of a stop
+func (s RunState) started() bool {
+	switch s {
+	case RunStateStarting, RunStateRunning, RunStatePaused, RunStateStopping:
+		return true
+	}
+	return false
+}
+
+// setState moves the bot to state, recording err for the error state
+func (b *GridBot) setState(state RunState, err error) {
+	b.mu.Lock()
+	previous := b.state
+	b.state = state
+	b.stateErr = err
+	b.mu.Unlock()
+
+	if state == previous {
+		return
+	}
+	if err != nil {
+		b.logger.Error("Bot state changed", "from", previous, "to", state, "error", err)
+		return
+	}
+	b.logger.Info("Bot state changed", "from", previous, "to", state)
+}
+
+// Pause stops trading while keeping the bot's fills and ledger: no fills are
+// handled and no orders placed until Resume. With keepOrders the open orders
+// stay on the book and fills while paused are booked on Resume; otherwise
+// they are canceled. If some orders cannot be canceled the bot is still
+// paused and the error is returned; Resume adopts the remaining orders.
+func (b *GridBot) Pause(ctx context.Context, keepOrders bool) error {
+	b.lifecycle.Lock()
+	defer b.lifecycle.Unlock()
+
+	b.mu.RLock()
+	state := b.state
+	b.mu.RUnlock()
+	switch state {
+	case RunStateRunning:
+	case RunStatePaused:
+		return ErrPaused
+	default:
+		return ErrNotRunning
+	}
+
+	b.logger.Info("Pausing grid bot", "keepOrders", keepOrders)
+	b.setState(RunStatePaused, nil)
+	if keepOrders {
+		b.stopLoop()
+		b.saveState()
+		return nil
+	}
+	return b.halt(ctx)
+}
+
+// Resume reconciles the tracked orders with the exchange, booking fills that
+// happened while paused, and places the grid orders around the current price
+// again. It fails with an *InsufficientBalanceError if the account cannot fund
+// them, leaving the bot paused.
+func (b *GridBot) Resume(ctx context.Context) error {
+	b.lifecycle.Lock()
+	defer b.lifecycle.Unlock()
+
+	b.mu.RLock()
+	state := b.state
+	b.mu.RUnlock()
+	switch state {
+	case RunStatePaused:
+	case RunStateRunning:
+		return ErrNotPaused
+	default:
+		return ErrNotRunning
+	}
+
+	b.logger.Info("Resuming grid bot")
+	if err := b.launch(ctx); err != nil {
+		return err
+	}
+	b.setState(RunStateRunning, nil)
+	return nil
+}
+
+// Reconfigure replaces the grid with one built from config, which must be for
+// the same symbol. The new levels always come from config's spacing. Orders
+// on levels both grids share stay open and orders on removed levels are
+// canceled; a running bot then places orders on the new levels around the
+// current price, while a paused bot stays paused. Fills are not handled while
+// the grid changes; orders that filled meanwhile are picked up by the
+// reconciliation that follows. It fails with a *ConfigError if config is
+// invalid.
+func (b *GridBot) Reconfigure(ctx context.Context, config GridBotConfig) error {
+	if err := validateConfig(config); err != nil {
+		return &ConfigError{Err: err}
+	}
+	config = withDefaults(config)
+
+	b.lifecycle.Lock()
+	defer b.lifecycle.Unlock()
+
+	b.mu.RLock()
+	symbol := b.config.Symbol
+	b.mu.RUnlock()
+	if config.Symbol != symbol {
+		return &ConfigError{Err: fmt.Errorf("cannot change symbol from %s to %s", symbol, config.Symbol)}
+	}
+
+	// Lay out the new grid with the current trading rules
+	next := &GridBot{config: config, logger: b.logger}
+	levels, err := levelGenerator(config).Levels()
+	if err != nil {
+		return &ConfigError{Err: fmt.Errorf("invalid grid parameters: %w", err)}
+	}
+	next.levels = levels
+	next.config.LowerPrice = levels[0]
+	next.config.UpperPrice = levels[len(levels)-1]
+	next.config.GridNum = len(levels)
+	if err := next.applySymbolInfo(b.symbolInfo); err != nil {
+		return &ConfigError{Err: fmt.Errorf("invalid grid parameters for %s: %w", config.Symbol, err)}
+	}
+	if err := next.checkGridProfit(); err != nil {
+		return &ConfigError{Err: fmt.Errorf("invalid grid parameters for %s: %w", config.Symbol, err)}
+	}
+	if err := next.sizeLevels(); err != nil {
+		return &ConfigError{Err: fmt.Errorf("invalid grid parameters for %s: %w", config.Symbol, err)}
+	}
+
+	b.mu.RLock()
+	trading := b.state == RunStateRunning
+	b.mu.RUnlock()
+
+	// Stop handling fills so orders keep their levels while the grid changes
+	b.stopLoop()
+
+	// Map every current level to its index in the new grid
+	b.mu.RLock()
+	moved := make(map[int]int, len(b.levels))
+	for i, price := range b.levels {
+		for j, level := range next.levels {
+			if onLevel(price, level) {
+				moved[i] = j
+				break
+			}
+		}
+	}
+	removed := make(map[string]gridOrder)
+	for orderID, order := range b.orders {
+		if _, ok := moved[order.level]; !ok {
+			removed[orderID] = order
+		}
+	}
+	b.mu.RUnlock()
+
+	var cancelErr error
+	for orderID, order := range removed {
+		if _, err := b.cancelOrder(ctx, orderID); err != nil {
+			b.logger.Error("Failed to cancel order on removed level", append(order.logAttrs(orderID), "error", err)...)
+			cancelErr = err
+		}
+	}
+	if cancelErr != nil {
+		b.saveState()
+		if trading {
+			if err := b.launch(ctx); err != nil {
+				return b.pauseAfter(ctx, fmt.Errorf("failed to cancel orders on removed levels (%v), then failed to restart the grid: %w", cancelErr, err))
+			}
+		}
+		return fmt.Errorf("failed to cancel orders on removed levels, grid unchanged: %w", cancelErr)
+	}
+
+	b.mu.Lock()
+	for orderID, order := range b.orders {
+		order.level = moved[order.level]
+		b.orders[orderID] = order
+	}
+	// Keep lots paired with the level they were bought at, or else the
+	// nearest new level below it
+	for i, lot := range b.ledger.Lots {
+		b.ledger.Lots[i].Level = levelsBelow(next.levels, lot.Price*(1+levelPriceTolerance)) - 1
+	}
+	b.shiftFailedOrders(func(level int) (int, bool) {
+		to, ok := moved[level]
+		return to, ok
+	})
+	kept := len(b.orders)
+	b.config = next.config
+	b.generator = nil
+	b.levels = next.levels
+	b.quantities = next.quantities
+	b.trailStall = ""
+	b.mu.Unlock()
+	b.saveState()
+
+	b.logger.Info("Reconfigured grid", "lowerPrice", next.config.LowerPrice, "upperPrice", next.config.UpperPrice,
+		"gridNum", next.config.GridNum, "spacing", next.config.Spacing, "sizing", next.config.Sizing,
+		"investment", next.config.Investment, "keptOrders", kept, "canceledOrders", len(removed))
+
+	if !trading {
+		return nil
+	}
+	if err := b.launch(ctx); err != nil {
+		return b.pauseAfter(ctx, fmt.Errorf("grid reconfigured but not started, bot is paused: %w", err))
+	}
+	return nil
+}
+
+// pauseAfter cancels the remaining orders of a bot that failed to restart its
+// grid and leaves it paused, returning err
+func (b *GridBot) pauseAfter(ctx context.Context, err error) error {
+	b.setState(RunStatePaused, nil)
+	if cancelErr := b.halt(ctx); cancelErr != nil {
+		b.logger.Error("Failed to cancel orders of the paused grid", "error", cancelErr)
+	}
+	return err
+}
+
+// OpenOrders returns the orders the bot is tracking, ordered by price
+func (b *GridBot) OpenOrders() []OrderRecord {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	records := make([]OrderRecord, 0, len(b.orders))
+	for orderID, order := range b.orders {
+		records = append(records, OrderRecord{
+			OrderID:       orderID,
+			ClientOrderID: order.order.ClientOrderID,
+			Level:         order.level,
+			Side:          order.order.Side,
+			Price:         order.order.Price,
+			Quantity:      order.order.Quantity,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Price < records[j].Price
+	})
+	return records
+}
+
+// Fills returns the bot's filled orders, oldest first
+func (b *GridBot) Fills() []Fill {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return append([]Fill(nil), b.fills...)
+}
@@ -0,0 +1,495 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/types"
+)
+
+func TestGridBotPauseResume(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Pause(ctx, false); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Pause before Start = %v, want %v", err, ErrNotRunning)
+	}
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	if err := bot.Resume(ctx); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("Resume while trading = %v, want %v", err, ErrNotPaused)
+	}
+
+	if err := bot.Pause(ctx, false); err != nil {
+		t.Fatalf("Failed to pause bot: %v", err)
+	}
+	if len(exchange.orders) != 0 {
+		t.Errorf("Expected pausing to cancel all orders, %d left", len(exchange.orders))
+	}
+	if status := bot.GetStatus(); !status.Running || !status.Paused || status.OpenOrders != 0 {
+		t.Errorf("Unexpected status while paused: running %v, paused %v, %d open orders",
+			status.Running, status.Paused, status.OpenOrders)
+	}
+	if err := bot.Pause(ctx, false); !errors.Is(err, ErrPaused) {
+		t.Errorf("Pause while paused = %v, want %v", err, ErrPaused)
+	}
+
+	// The grid is placed around the price at the time of resuming
+	exchange.currentPrice = 28000.0
+	if err := bot.Resume(ctx); err != nil {
+		t.Fatalf("Failed to resume bot: %v", err)
+	}
+	if status := bot.GetStatus(); status.Paused || status.OpenOrders != 4 {
+		t.Errorf("Expected 4 open orders after resuming, got %d (paused %v)", status.OpenOrders, status.Paused)
+	}
+	if _, ok := exchange.openOrder("SELL", 30000.0); !ok {
+		t.Error("Expected a sell order at 30000 after resuming below it")
+	}
+}
+
+func TestGridBotReconfigure(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	tests := []struct {
+		name       string
+		modify     func(*GridBotConfig)
+		wantErr    bool
+		wantOrders int
+	}{
+		{
+			name:    "Different symbol",
+			modify:  func(c *GridBotConfig) { c.Symbol = "ETHUSDT" },
+			wantErr: true,
+		},
+		{
+			name:    "Invalid grid",
+			modify:  func(c *GridBotConfig) { c.LowerPrice = 40000.0 },
+			wantErr: true,
+		},
+		{
+			name: "Wider grid",
+			modify: func(c *GridBotConfig) {
+				c.LowerPrice, c.UpperPrice, c.GridNum = 20000.0, 40000.0, 9
+			},
+			wantOrders: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := bot.GetStatus().Config
+			tt.modify(&next)
+			before := bot.GetStatus().Config
+
+			err := bot.Reconfigure(ctx, next)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				if after := bot.GetStatus().Config; after.GridNum != before.GridNum || after.LowerPrice != before.LowerPrice {
+					t.Errorf("Failed reconfiguration changed the grid to %+v", after)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to reconfigure: %v", err)
+			}
+
+			status := bot.GetStatus()
+			if status.Config.GridNum != next.GridNum || len(status.Levels) != next.GridNum {
+				t.Errorf("Expected %d levels, got %d", next.GridNum, len(status.Levels))
+			}
+			if status.OpenOrders != tt.wantOrders || len(exchange.orders) != tt.wantOrders {
+				t.Errorf("Expected %d open orders, bot tracks %d and exchange has %d",
+					tt.wantOrders, status.OpenOrders, len(exchange.orders))
+			}
+			if _, ok := exchange.openOrder("BUY", 20000.0); !ok {
+				t.Error("Expected a buy order at the new lower bound")
+			}
+		})
+	}
+}
+
+func TestGridBotReconfigureKeepsOrders(t *testing.T) {
+	type order struct {
+		side  string
+		price float64
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(*GridBotConfig)
+		price      float64   // Price when reconfiguring
+		filled     *order    // Order that fills while the grid changes
+		wantOrders []order   // Open orders after reconfiguring, by price
+		wantKept   []float64 // Prices of orders that must stay open unchanged
+		wantFills  int
+	}{
+		{
+			name: "Narrower",
+			modify: func(c *GridBotConfig) {
+				c.LowerPrice, c.UpperPrice, c.GridNum = 27500.0, 32500.0, 3
+			},
+			price:      31000.0,
+			wantOrders: []order{{"BUY", 27500}, {"SELL", 32500}},
+			wantKept:   []float64{27500, 32500},
+		},
+		{
+			name: "Finer with a fill",
+			modify: func(c *GridBotConfig) {
+				c.GridNum = 9
+			},
+			price:  27000.0,
+			filled: &order{"BUY", 27500},
+			wantOrders: []order{
+				{"BUY", 25000}, {"BUY", 26250}, {"SELL", 28750}, {"SELL", 30000},
+				{"SELL", 31250}, {"SELL", 32500}, {"SELL", 33750}, {"SELL", 35000},
+			},
+			wantKept:  []float64{25000, 32500, 35000},
+			wantFills: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GridBotConfig{
+				Symbol:       "BTCUSDT",
+				LowerPrice:   25000.0,
+				UpperPrice:   35000.0,
+				GridNum:      5,
+				Investment:   1000.0,
+				PollInterval: time.Hour,
+			}
+
+			exchange := &mockExchange{
+				currentPrice: 31000.0,
+				orders:       make(map[string]mockOrder),
+			}
+
+			bot, err := NewGridBot(exchange, config)
+			if err != nil {
+				t.Fatalf("Failed to create bot: %v", err)
+			}
+
+			ctx := context.Background()
+			if err := bot.Start(ctx); err != nil {
+				t.Fatalf("Failed to start bot: %v", err)
+			}
+			defer bot.Stop(ctx)
+
+			kept := make(map[float64]string)
+			for _, price := range tt.wantKept {
+				for _, record := range bot.OpenOrders() {
+					if record.Price == price {
+						kept[price] = record.OrderID
+					}
+				}
+			}
+			if tt.filled != nil {
+				exchange.fill(t, tt.filled.side, tt.filled.price)
+			}
+			exchange.currentPrice = tt.price
+
+			next := bot.GetStatus().Config
+			tt.modify(&next)
+			if err := bot.Reconfigure(ctx, next); err != nil {
+				t.Fatalf("Failed to reconfigure: %v", err)
+			}
+
+			var orders []order
+			for _, record := range bot.OpenOrders() {
+				orders = append(orders, order{record.Side, record.Price})
+				if orderID, ok := kept[record.Price]; ok && orderID != record.OrderID {
+					t.Errorf("Order at %.2f was replaced: %s, want %s", record.Price, record.OrderID, orderID)
+				}
+			}
+			if !slices.Equal(orders, tt.wantOrders) {
+				t.Errorf("Open orders = %v, want %v", orders, tt.wantOrders)
+			}
+			if len(exchange.orders) != len(tt.wantOrders) {
+				t.Errorf("Exchange has %d open orders, want %d", len(exchange.orders), len(tt.wantOrders))
+			}
+
+			status := bot.GetStatus()
+			if status.Fills != tt.wantFills {
+				t.Errorf("Fills = %d, want %d", status.Fills, tt.wantFills)
+			}
+			// Every tracked order sits on its level in the new grid
+			for _, level := range status.Levels {
+				if level.OrderID == "" {
+					continue
+				}
+				if _, ok := exchange.openOrder(level.Side, level.Price); !ok {
+					t.Errorf("Level %.2f tracks %s order %s not open on the exchange", level.Price, level.Side, level.OrderID)
+				}
+			}
+		})
+	}
+}
+
+func TestGridBotPauseKeepsOrders(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	if err := bot.Pause(ctx, true); err != nil {
+		t.Fatalf("Failed to pause bot: %v", err)
+	}
+	status := bot.GetStatus()
+	if status.State != RunStatePaused || status.OpenOrders != 4 || len(exchange.orders) != 4 {
+		t.Errorf("Expected a paused bot with its 4 orders open, got state %s, %d tracked, %d on the exchange",
+			status.State, status.OpenOrders, len(exchange.orders))
+	}
+
+	// The 32500 sell fills while paused; no counter-order is placed
+	exchange.fill(t, "SELL", 32500.0)
+	exchange.currentPrice = 33000.0
+	bot.CheckOrders(ctx)
+	if _, ok := exchange.openOrder("BUY", 30000.0); ok {
+		t.Error("Expected no counter-order while paused")
+	}
+
+	// Resuming books the fill and places its counter-order
+	if err := bot.Resume(ctx); err != nil {
+		t.Fatalf("Failed to resume bot: %v", err)
+	}
+	status = bot.GetStatus()
+	if status.State != RunStateRunning || status.Fills != 1 {
+		t.Errorf("Expected a running bot with 1 fill, got state %s, %d fills", status.State, status.Fills)
+	}
+	if _, ok := exchange.openOrder("BUY", 30000.0); !ok {
+		t.Error("Expected a counter-order at 30000 after resuming")
+	}
+	for _, price := range []float64{25000.0, 27500.0} {
+		if _, ok := exchange.openOrder("BUY", price); !ok {
+			t.Errorf("Expected the buy order at %.2f to stay open", price)
+		}
+	}
+}
+
+func TestGridBotStopBooksOrdersFilledMeanwhile(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	sim, err := exchange.NewSimulatedExchange(exchange.SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 1000, "BTC": 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create simulated exchange: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sim.SetPrice(start, 31000.0)
+
+	bot, err := NewGridBot(sim, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+
+	// The 27500 buy fills before the bot checks its orders, so canceling it
+	// fails with an unknown order
+	sim.SetPrice(start.Add(time.Minute), 27000.0)
+	if err := bot.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop bot: %v", err)
+	}
+
+	status := bot.GetStatus()
+	if status.State != RunStateStopped || status.OpenOrders != 0 {
+		t.Errorf("Expected a stopped bot with no orders, got state %s, %d orders", status.State, status.OpenOrders)
+	}
+	if len(bot.fills) != 1 || bot.fills[0].Side != "BUY" || bot.fills[0].Price != 27500.0 {
+		t.Errorf("Expected the 27500 buy to be booked, got fills %+v", bot.fills)
+	}
+	open, err := sim.GetOpenOrders(ctx, config.Symbol)
+	if err != nil {
+		t.Fatalf("GetOpenOrders() error = %v", err)
+	}
+	if len(open) != 0 {
+		t.Errorf("Expected no counter-order after stopping, got %d open orders", len(open))
+	}
+}
+
+// failingExchange fails price lookups, cancellations and order placements on
+// demand
+type failingExchange struct {
+	*mockExchange
+	failPrice  bool
+	failCancel bool
+	placeErr   error
+}
+
+func (e *failingExchange) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	if e.placeErr != nil {
+		return "", e.placeErr
+	}
+	return e.mockExchange.PlaceOrder(ctx, order)
+}
+
+func (e *failingExchange) GetSymbolPrice(ctx context.Context, symbol string) (float64, error) {
+	if e.failPrice {
+		return 0, errors.New("exchange unavailable")
+	}
+	return e.mockExchange.GetSymbolPrice(ctx, symbol)
+}
+
+func (e *failingExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	if e.failCancel {
+		return errors.New("exchange unavailable")
+	}
+	return e.mockExchange.CancelOrder(ctx, symbol, orderID)
+}
+
+func TestGridBotRunStates(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &failingExchange{mockExchange: &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	ctx := context.Background()
+
+	assertState := func(want RunState, wantError bool) {
+		t.Helper()
+		status := bot.GetStatus()
+		if status.State != want || (status.Error != "") != wantError {
+			t.Errorf("State = %s (error %q), want %s with error %v", status.State, status.Error, want, wantError)
+		}
+	}
+
+	assertState(RunStateIdle, false)
+	if err := bot.Stop(ctx); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Stop before Start = %v, want %v", err, ErrNotRunning)
+	}
+
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	assertState(RunStateRunning, false)
+	if err := bot.Start(ctx); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("Start while running = %v, want %v", err, ErrAlreadyRunning)
+	}
+
+	// Orders that cannot be canceled leave the bot in the error state
+	exchange.failCancel = true
+	if err := bot.Stop(ctx); err == nil {
+		t.Fatal("Expected Stop to fail")
+	}
+	assertState(RunStateError, true)
+	if status := bot.GetStatus(); status.Running || status.OpenOrders != 4 {
+		t.Errorf("Expected a stopped bot still tracking 4 orders, got running %v, %d orders",
+			status.Running, status.OpenOrders)
+	}
+
+	// A bot in the error state can be started again and adopts its orders
+	exchange.failCancel = false
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to restart bot: %v", err)
+	}
+	assertState(RunStateRunning, false)
+	if len(exchange.orders) != 4 {
+		t.Errorf("Expected the 4 orders to be adopted, exchange has %d", len(exchange.orders))
+	}
+
+	if err := bot.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop bot: %v", err)
+	}
+	assertState(RunStateStopped, false)
+
+	// A failed start is an error
+	exchange.failPrice = true
+	if err := bot.Start(ctx); err == nil {
+		t.Fatal("Expected Start to fail without a price")
+	}
+	assertState(RunStateError, true)
+}
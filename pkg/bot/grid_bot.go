@@ -2,21 +2,67 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"sync"
+	"time"
 
 	"spot_grid_bot/pkg/grid"
 	"spot_grid_bot/pkg/types"
 )
 
+const (
+	// defaultPollInterval is used when GridBotConfig.PollInterval is not set
+	defaultPollInterval = 5 * time.Second
+	// minPollInterval keeps order status checks within the exchange's rate
+	// limits
+	minPollInterval = time.Second
+	// symbolInfoTimeout bounds the symbol info lookup in NewGridBot
+	symbolInfoTimeout = 10 * time.Second
+	// defaultConcentration is used for weighted spacing when
+	// GridBotConfig.Concentration is not set
+	defaultConcentration = 2.0
+	// defaultSizeMultiplier is used for martingale sizing when
+	// GridBotConfig.SizeMultiplier is not set
+	defaultSizeMultiplier = 1.5
+	// streamPollInterval is how often order status is polled as a safety net
+	// while execution reports are streamed
+	streamPollInterval = 5 * time.Minute
+)
+
 // GridBotConfig holds the configuration for the grid trading bot
 type GridBotConfig struct {
-	Symbol     string  // Trading pair symbol (e.g., "BTCUSDT")
-	LowerPrice float64 // Lower price bound of the grid
-	UpperPrice float64 // Upper price bound of the grid
-	GridNum    int     // Number of grid levels
-	Investment float64 // Total investment amount in quote currency
+	Symbol       string        `json:"symbol"`       // Trading pair symbol (e.g., "BTCUSDT")
+	LowerPrice   float64       `json:"lowerPrice"`   // Lower price bound of the grid
+	UpperPrice   float64       `json:"upperPrice"`   // Upper price bound of the grid
+	GridNum      int           `json:"gridNum"`      // Number of grid levels
+	Investment   float64       `json:"investment"`   // Total investment amount in quote currency
+	Spacing      grid.Spacing  `json:"spacing"`      // Level spacing: arithmetic (default), geometric, explicit or weighted
+	PollInterval time.Duration `json:"pollInterval"` // Interval between order status checks while fills are not streamed, at least 1s (default 5s)
+
+	Levels        []float64 `json:"levels,omitempty"`        // Price levels for explicit spacing
+	CenterPrice   float64   `json:"centerPrice,omitempty"`   // Densest price for weighted spacing (default: mid of the range)
+	Concentration float64   `json:"concentration,omitempty"` // Density around the center for weighted spacing, >= 1 (default 2)
+
+	Sizing         grid.Sizing `json:"sizing"`                   // Position sizing: equal-quote (default), equal-base or martingale
+	SizeMultiplier float64     `json:"sizeMultiplier,omitempty"` // Per-level growth for martingale sizing, >= 1 (default 1.5)
+
+	AcquireBase bool `json:"acquireBase,omitempty"` // Market buy missing base asset for the sell orders on start
+
+	Fees          grid.Fees         `json:"fees"`                    // Trading fees, used to check that every grid is profitable
+	MinGridProfit float64           `json:"minGridProfit,omitempty"` // Minimum net profit per round trip as a fraction of the buy value (default 0)
+	ProfitPolicy  grid.ProfitPolicy `json:"profitPolicy,omitempty"`  // Handling of grids below MinGridProfit: reject (default) or warn
+
+	StopLossPrice    float64    `json:"stopLossPrice,omitempty"`    // Stop the grid when the price falls to this price (default: off)
+	TakeProfitPrice  float64    `json:"takeProfitPrice,omitempty"`  // Stop the grid when the price rises to this price (default: off)
+	StopLossAction   StopAction `json:"stopLossAction,omitempty"`   // What to do with the holdings on stop-loss: none (default), sell or buy
+	TakeProfitAction StopAction `json:"takeProfitAction,omitempty"` // What to do with the holdings on take-profit: none (default), sell or buy
+
+	Trailing        TrailMode `json:"trailing,omitempty"`        // Follow the price out of the range: off (default), up, down or both
+	TrailUpperLimit float64   `json:"trailUpperLimit,omitempty"` // Highest level the grid may trail up to (default: no limit)
+	TrailLowerLimit float64   `json:"trailLowerLimit,omitempty"` // Lowest level the grid may trail down to (default: no limit)
 }
 
 // Exchange defines the interface for interacting with the exchange
@@ -24,38 +70,307 @@ type Exchange interface {
 	GetSymbolPrice(ctx context.Context, symbol string) (float64, error)
 	PlaceOrder(ctx context.Context, order types.Order) (string, error)
 	CancelOrder(ctx context.Context, symbol, orderID string) error
+	GetOrder(ctx context.Context, symbol, orderID string) (types.OrderInfo, error)
+	GetOpenOrders(ctx context.Context, symbol string) ([]types.OrderInfo, error)
 	GetBalance(ctx context.Context, asset string) (float64, error)
 }
 
+// ExecutionReportStreamer is implemented by exchanges that push order updates.
+// When available the bot reacts to fills as they are reported and polls
+// order status only every few minutes as a safety net.
+type ExecutionReportStreamer interface {
+	SubscribeExecutionReports(ctx context.Context) (<-chan types.ExecutionReport, error)
+}
+
+// ExecutionReportMonitor is implemented by streaming exchanges whose stream
+// can drop. While it is down, and once after it reconnects, the bot polls
+// order status every PollInterval.
+type ExecutionReportMonitor interface {
+	// ExecutionReportsConnectedSince returns when the stream last connected,
+	// or the zero time while it is down
+	ExecutionReportsConnectedSince() time.Time
+}
+
+// PriceStreamer is implemented by exchanges that push live prices. When
+// available the bot tracks the live price to detect range breakouts.
+type PriceStreamer interface {
+	SubscribePrice(ctx context.Context, symbol string) (<-chan types.PriceUpdate, error)
+}
+
+// SymbolInfoProvider is implemented by exchanges that expose a symbol's
+// trading rules. When available the bot rounds its grid to the symbol's tick
+// and step size and rejects grids whose orders fall below the minimum notional.
+type SymbolInfoProvider interface {
+	GetSymbolInfo(ctx context.Context, symbol string) (types.SymbolInfo, error)
+}
+
+// gridOrder is an open order placed by the bot at a grid level
+type gridOrder struct {
+	order types.Order
+	level int // Index into GridBot.levels
+}
+
+// logAttrs returns the log attributes identifying the order. The grid level
+// is logged as gridLevel since level is the log record's severity.
+func (o gridOrder) logAttrs(orderID string) []any {
+	return []any{
+		"orderID", orderID,
+		"gridLevel", o.level,
+		"side", o.order.Side,
+		"price", o.order.Price,
+		"qty", o.order.Quantity,
+	}
+}
+
 // GridBot implements a grid trading strategy
 type GridBot struct {
-	exchange Exchange
-	config   GridBotConfig
-	levels   []float64
-	orders   map[string]types.Order
-	mu       sync.RWMutex
-	running  bool
+	exchange   Exchange
+	config     GridBotConfig
+	symbolInfo types.SymbolInfo    // Trading rules, zero if the exchange does not provide them
+	generator  grid.LevelGenerator // Optional override for the configured spacing
+	levels     []float64
+	quantities []float64 // Base quantity of a fresh order at each level
+	orders     map[string]gridOrder
+	failed     map[int]failedOrder // Orders to place again after a retryable failure, by level
+	mu         sync.RWMutex
+	lifecycle  sync.Mutex // Serializes Start, Stop, Pause, Resume and Reconfigure and all state changes
+	state      RunState
+	stateErr   error              // Why the bot is in the error state
+	runCtx     context.Context    // Context passed to Start, parent of the watch loop
+	cancel     context.CancelFunc // Stops the watch loop
+	done       chan struct{}      // Closed when the watch loop exits
+
+	startPrice  float64   // Price when the bot was started
+	lastPrice   float64   // Latest known price
+	interval    int       // Number of levels at or below lastPrice
+	outOfRange  bool      // Whether lastPrice is outside the grid
+	startedAt   time.Time // When the bot was last started
+	lastPriceAt time.Time // When lastPrice was observed
+	lastFillAt  time.Time // When the last fill was handled
+
+	triggered  bool          // Whether a stop-loss or take-profit fired since Start
+	stopReason StopReason    // Why the bot last stopped
+	stoppedAt  time.Time     // When the bot last stopped
+	stopped    chan struct{} // Closed when the running bot stops
+	trailStall string        // Why the grid last could not trail, to log it once
+
+	lastBalances map[string]float64 // Free balances as last queried
+	balancesAt   time.Time          // When lastBalances were queried
+	filled       map[string]float64 // Filled quantity of partially filled orders by order ID
+
+	logger      *slog.Logger // Logs with the symbol attached
+	store       StateStore   // Optional persistence for restarts
+	fills       []Fill
+	ledger      Ledger
+	partialFees map[string][]feeCharge // Commissions of partially filled orders by order ID
 }
 
+// Option configures optional GridBot behavior
+type Option func(*GridBot)
+
 // NewGridBot creates a new grid trading bot
-func NewGridBot(exchange Exchange, config GridBotConfig) (*GridBot, error) {
+func NewGridBot(exchange Exchange, config GridBotConfig, opts ...Option) (*GridBot, error) {
 	// Validate configuration
 	if err := validateConfig(config); err != nil {
 		return nil, err
 	}
 
-	// Calculate grid levels
-	levels := grid.CalculateGridLevels(config.LowerPrice, config.UpperPrice, config.GridNum)
-	if levels == nil {
-		return nil, fmt.Errorf("failed to calculate grid levels")
-	}
+	config = withDefaults(config)
 
-	return &GridBot{
+	b := &GridBot{
 		exchange: exchange,
 		config:   config,
-		levels:   levels,
-		orders:   make(map[string]types.Order),
-	}, nil
+		orders:   make(map[string]gridOrder),
+		state:    RunStateIdle,
+
+		stopped:      make(chan struct{}),
+		partialFees:  make(map[string][]feeCharge),
+		lastBalances: make(map[string]float64),
+		filled:       make(map[string]float64),
+		failed:       make(map[int]failedOrder),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.logger == nil {
+		b.logger = slog.Default()
+	}
+	b.logger = b.logger.With("symbol", config.Symbol)
+
+	// Calculate grid levels
+	generator := b.generator
+	if generator == nil {
+		generator = levelGenerator(config)
+	}
+	levels, err := generator.Levels()
+	if err != nil {
+		return nil, fmt.Errorf("invalid grid parameters: %w", err)
+	}
+	b.levels = levels
+
+	// The generated levels define the bounds of the grid
+	b.config.LowerPrice = levels[0]
+	b.config.UpperPrice = levels[len(levels)-1]
+	b.config.GridNum = len(levels)
+
+	// Fit the grid to the symbol's trading rules
+	if provider, ok := exchange.(SymbolInfoProvider); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), symbolInfoTimeout)
+		defer cancel()
+
+		info, err := provider.GetSymbolInfo(ctx, config.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get symbol info: %w", err)
+		}
+		if err := b.applySymbolInfo(info); err != nil {
+			return nil, fmt.Errorf("invalid grid parameters for %s: %w", config.Symbol, err)
+		}
+	}
+
+	if err := b.checkGridProfit(); err != nil {
+		return nil, fmt.Errorf("invalid grid parameters for %s: %w", config.Symbol, err)
+	}
+
+	if err := b.sizeLevels(); err != nil {
+		return nil, fmt.Errorf("invalid grid parameters for %s: %w", config.Symbol, err)
+	}
+
+	return b, nil
+}
+
+// WithLogger makes the bot log to logger instead of the default logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *GridBot) {
+		b.logger = logger
+	}
+}
+
+// withDefaults fills in the defaults for unset configuration fields
+func withDefaults(config GridBotConfig) GridBotConfig {
+	if config.Spacing == "" {
+		config.Spacing = grid.SpacingArithmetic
+	}
+	if config.Sizing == "" {
+		config.Sizing = grid.SizingEqualQuote
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.ProfitPolicy == "" {
+		config.ProfitPolicy = grid.ProfitPolicyReject
+	}
+	if config.StopLossAction == "" {
+		config.StopLossAction = StopActionNone
+	}
+	if config.TakeProfitAction == "" {
+		config.TakeProfitAction = StopActionNone
+	}
+	if config.Trailing == "" {
+		config.Trailing = TrailOff
+	}
+	return config
+}
+
+// WithLevelGenerator makes the bot take its price levels from generator
+// instead of the spacing configured in GridBotConfig
+func WithLevelGenerator(generator grid.LevelGenerator) Option {
+	return func(b *GridBot) {
+		b.generator = generator
+	}
+}
+
+// levelGenerator returns the level generator described by the configuration
+func levelGenerator(config GridBotConfig) grid.LevelGenerator {
+	switch config.Spacing {
+	case grid.SpacingGeometric:
+		return grid.GeometricGenerator{
+			LowerPrice: config.LowerPrice,
+			UpperPrice: config.UpperPrice,
+			GridNum:    config.GridNum,
+		}
+	case grid.SpacingExplicit:
+		return grid.ExplicitGenerator{Prices: config.Levels}
+	case grid.SpacingWeighted:
+		center := config.CenterPrice
+		if center == 0 {
+			center = (config.LowerPrice + config.UpperPrice) / 2
+		}
+		concentration := config.Concentration
+		if concentration == 0 {
+			concentration = defaultConcentration
+		}
+		return grid.WeightedGenerator{
+			LowerPrice:    config.LowerPrice,
+			UpperPrice:    config.UpperPrice,
+			GridNum:       config.GridNum,
+			CenterPrice:   center,
+			Concentration: concentration,
+		}
+	default:
+		return grid.ArithmeticGenerator{
+			LowerPrice: config.LowerPrice,
+			UpperPrice: config.UpperPrice,
+			GridNum:    config.GridNum,
+		}
+	}
+}
+
+// applySymbolInfo rounds the grid levels to the symbol's tick size
+func (b *GridBot) applySymbolInfo(info types.SymbolInfo) error {
+	levels, err := grid.RoundLevels(b.levels, info.TickSize)
+	if err != nil {
+		return err
+	}
+	b.levels = levels
+	b.symbolInfo = info
+	return nil
+}
+
+// checkGridProfit checks that every grid nets at least MinGridProfit per
+// round trip after fees, failing or only logging depending on ProfitPolicy
+func (b *GridBot) checkGridProfit() error {
+	err := grid.ValidateGridProfit(b.levels, b.config.Fees, b.config.MinGridProfit)
+	if err != nil && b.config.ProfitPolicy == grid.ProfitPolicyWarn {
+		b.logger.Warn("Grid is below the minimum profit", "error", err)
+		return nil
+	}
+	return err
+}
+
+// sizeLevels splits the investment across the grid levels
+func (b *GridBot) sizeLevels() error {
+	quantities, err := b.levelQuantities(b.levels)
+	if err != nil {
+		return err
+	}
+	b.quantities = quantities
+	return nil
+}
+
+// levelQuantities splits the investment across levels using the configured
+// sizing and checks the resulting orders against the investment and the
+// symbol's minimum notional
+func (b *GridBot) levelQuantities(levels []float64) ([]float64, error) {
+	multiplier := b.config.SizeMultiplier
+	if multiplier == 0 {
+		multiplier = defaultSizeMultiplier
+	}
+	quantities, err := grid.CalculateQuantities(levels, b.config.Investment, b.config.Sizing, multiplier, b.symbolInfo.StepSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := grid.ValidateInvestment(levels, quantities, b.config.Investment); err != nil {
+		return nil, err
+	}
+	if err := grid.ValidateMinNotional(levels, quantities, b.symbolInfo.MinNotional); err != nil {
+		return nil, err
+	}
+	return quantities, nil
+}
+
+// levelQuantity returns the base quantity of a fresh order at a level
+func (b *GridBot) levelQuantity(level int) float64 {
+	return b.quantities[level]
 }
 
 // validateConfig validates the bot configuration
@@ -66,113 +381,599 @@ func validateConfig(config GridBotConfig) error {
 	if config.Investment <= 0 {
 		return fmt.Errorf("investment must be positive")
 	}
-	if err := grid.ValidateGridParams(config.LowerPrice, config.UpperPrice, config.GridNum); err != nil {
-		return fmt.Errorf("invalid grid parameters: %w", err)
+	if config.PollInterval != 0 && config.PollInterval < minPollInterval {
+		return fmt.Errorf("poll interval must be at least %s", minPollInterval)
 	}
-	return nil
+	if config.Spacing != "" {
+		if _, err := grid.ParseSpacing(string(config.Spacing)); err != nil {
+			return err
+		}
+	}
+	if config.Sizing != "" {
+		if _, err := grid.ParseSizing(string(config.Sizing)); err != nil {
+			return err
+		}
+	}
+	if err := config.Fees.Validate(); err != nil {
+		return err
+	}
+	if config.MinGridProfit < 0 {
+		return fmt.Errorf("minimum grid profit must not be negative")
+	}
+	if config.ProfitPolicy != "" {
+		if _, err := grid.ParseProfitPolicy(string(config.ProfitPolicy)); err != nil {
+			return err
+		}
+	}
+	if err := validateTriggers(config); err != nil {
+		return err
+	}
+	return validateTrailing(config)
 }
 
-// Start initializes the grid and starts the trading bot
+// Start initializes the grid and starts the trading bot. It fails with an
+// *InsufficientBalanceError if the account cannot fund the grid orders.
 func (b *GridBot) Start(ctx context.Context) error {
+	b.lifecycle.Lock()
+	defer b.lifecycle.Unlock()
+
 	b.mu.Lock()
-	if b.running {
+	if b.state.started() {
 		b.mu.Unlock()
-		return fmt.Errorf("bot is already running")
+		return ErrAlreadyRunning
+	}
+	b.runCtx = ctx
+	b.triggered = false
+	b.stopReason = ""
+	b.stoppedAt = time.Time{}
+	select {
+	case <-b.stopped:
+		b.stopped = make(chan struct{})
+	default:
 	}
-	b.running = true
 	b.mu.Unlock()
+	b.setState(RunStateStarting, nil)
+
+	// Resume from saved state, if any
+	if err := b.restoreState(); err != nil {
+		b.setState(RunStateError, err)
+		return err
+	}
+
+	if err := b.launch(ctx); err != nil {
+		b.setState(RunStateError, err)
+		return err
+	}
+	b.setState(RunStateRunning, nil)
+	return nil
+}
 
+// launch places the grid orders around the current price and starts watching
+// them. The watch loop runs until the context passed to Start is canceled or
+// the bot is halted; ctx only bounds the setup.
+func (b *GridBot) launch(ctx context.Context) error {
 	// Get current price
 	currentPrice, err := b.exchange.GetSymbolPrice(ctx, b.config.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get current price: %w", err)
 	}
+	if reason, _, crossed := b.crossedTrigger(currentPrice); crossed {
+		return fmt.Errorf("current price %.2f is already past the %s price", currentPrice, reason)
+	}
 
-	// Calculate order quantities
-	quantityPerGrid := b.config.Investment / float64(b.config.GridNum*2) // Split investment across grids
+	// Subscribe to order updates before placing orders so no fill is missed
+	b.mu.RLock()
+	loopCtx, cancel := context.WithCancel(b.runCtx)
+	b.mu.RUnlock()
+	var reports <-chan types.ExecutionReport
+	if streamer, ok := b.exchange.(ExecutionReportStreamer); ok {
+		reports, err = streamer.SubscribeExecutionReports(loopCtx)
+		if err != nil {
+			b.logger.Warn("Failed to subscribe to execution reports, falling back to polling", "error", err)
+		}
+	}
 
-	// Place initial orders
-	for _, level := range b.levels {
-		// Skip levels too close to current price
-		if level == currentPrice {
-			continue
+	var prices <-chan types.PriceUpdate
+	if streamer, ok := b.exchange.(PriceStreamer); ok {
+		prices, err = streamer.SubscribePrice(loopCtx, b.config.Symbol)
+		if err != nil {
+			b.logger.Warn("Failed to subscribe to price stream", "error", err)
 		}
+	}
 
-		var order types.Order
-		if level < currentPrice {
-			// Place buy order
-			order = types.Order{
-				Symbol:      b.config.Symbol,
-				Side:        "BUY",
-				Type:        "LIMIT",
-				Quantity:    quantityPerGrid / level, // Convert quote currency to base currency
-				Price:       level,
-				TimeInForce: "GTC",
+	b.mu.Lock()
+	b.startPrice = currentPrice
+	b.lastPrice = currentPrice
+	b.startedAt = time.Now()
+	b.lastPriceAt = b.startedAt
+	b.interval = levelsBelow(b.levels, currentPrice)
+	b.outOfRange = currentPrice < b.levels[0] || currentPrice > b.levels[len(b.levels)-1]
+	clear(b.failed) // Empty levels are filled by the reconciliation
+	b.mu.Unlock()
+
+	// Adopt orders already on the exchange and only fill the gaps
+	if err := b.reconcile(ctx, currentPrice); err != nil {
+		cancel()
+		return fmt.Errorf("failed to set up grid orders: %w", err)
+	}
+
+	// Base asset backing the sell orders that the ledger does not hold yet,
+	// such as the account's holdings on a fresh start, is opening inventory
+	if b.openInventory(currentPrice) {
+		b.saveState()
+	}
+
+	// Start watching orders for fills
+	done := make(chan struct{})
+	b.mu.Lock()
+	b.cancel = cancel
+	b.done = done
+	b.mu.Unlock()
+	go b.run(loopCtx, done, reports, prices)
+
+	return nil
+}
+
+// run reacts to execution reports and price updates and periodically
+// reconciles tracked orders until ctx is canceled, then closes done. reports
+// and prices may be nil if the exchange does not stream them.
+func (b *GridBot) run(ctx context.Context, done chan struct{}, reports <-chan types.ExecutionReport, prices <-chan types.PriceUpdate) {
+	defer close(done)
+
+	ticker := time.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+	lastPoll := time.Now() // Launching reconciled every order
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case report, ok := <-reports:
+			if !ok {
+				// Stream ended; keep running on polling alone
+				reports = nil
+				continue
 			}
-		} else {
-			// Place sell order
-			order = types.Order{
-				Symbol:      b.config.Symbol,
-				Side:        "SELL",
-				Type:        "LIMIT",
-				Quantity:    quantityPerGrid / level,
-				Price:       level,
-				TimeInForce: "GTC",
+			b.handleReport(ctx, report)
+		case update, ok := <-prices:
+			if !ok {
+				prices = nil
+				continue
 			}
+			b.updatePrice(ctx, update.Price, true)
+		case <-ticker.C:
+			if b.pollDue(reports != nil, lastPoll) {
+				b.checkOrders(ctx)
+				lastPoll = time.Now()
+			}
+			b.retryFailedOrders(ctx)
 		}
+	}
+}
 
-		orderID, err := b.exchange.PlaceOrder(ctx, order)
-		if err != nil {
-			log.Printf("Failed to place order at level %v: %v", level, err)
-			continue
+// pollDue reports whether tracked orders need their status polled: on every
+// tick without a connected execution report stream, once after the stream
+// reconnects and every streamPollInterval while it is up
+func (b *GridBot) pollDue(streaming bool, lastPoll time.Time) bool {
+	if !streaming {
+		return true
+	}
+	if monitor, ok := b.exchange.(ExecutionReportMonitor); ok {
+		if since := monitor.ExecutionReportsConnectedSince(); since.IsZero() || since.After(lastPoll) {
+			return true
 		}
+	}
+	return time.Since(lastPoll) >= max(streamPollInterval, b.config.PollInterval)
+}
+
+// handleReport reacts to an execution report for a tracked order. Reports for
+// orders the bot does not track, including orders whose placement has not yet
+// returned, are ignored and picked up by the next poll instead.
+func (b *GridBot) handleReport(ctx context.Context, report types.ExecutionReport) {
+	if report.Symbol != b.config.Symbol {
+		return
+	}
+
+	b.mu.RLock()
+	order, tracked := b.orders[report.OrderID]
+	b.mu.RUnlock()
+	if !tracked {
+		return
+	}
 
+	switch report.Status {
+	case types.OrderStatusFilled:
+		b.mu.Lock()
+		charges := append(b.partialFees[report.OrderID], reportFee(report)...)
+		delete(b.partialFees, report.OrderID)
+		delete(b.filled, report.OrderID)
+		b.mu.Unlock()
+		if charges == nil {
+			charges = []feeCharge{}
+		}
+		b.handleFill(ctx, report.OrderID, charges)
+	case types.OrderStatusPartiallyFilled:
 		b.mu.Lock()
-		b.orders[orderID] = order
+		b.partialFees[report.OrderID] = append(b.partialFees[report.OrderID], reportFee(report)...)
+		b.filled[report.OrderID] = report.CumulativeQuantity
 		b.mu.Unlock()
+		b.logger.Info("Order partially filled",
+			append(order.logAttrs(report.OrderID), "filledQty", report.CumulativeQuantity)...)
+	case types.OrderStatusCanceled, types.OrderStatusRejected, types.OrderStatusExpired:
+		b.dropOrder(report.OrderID, report.Status)
+	}
+}
 
-		log.Printf("Placed %s order at price %.2f, quantity %.8f", order.Side, order.Price, order.Quantity)
+// handlePrice records the latest price and logs range breakouts and moves
+// between grid intervals. It returns the stop-loss or take-profit price has
+// crossed, if it fired for the first time since Start.
+func (b *GridBot) handlePrice(price float64) (StopReason, StopAction, bool) {
+	if price <= 0 {
+		return "", "", false
 	}
 
-	return nil
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lower, upper := b.levels[0], b.levels[len(b.levels)-1]
+	outOfRange := price < lower || price > upper
+	interval := levelsBelow(b.levels, price)
+	drift := (price - b.startPrice) / b.startPrice * 100
+
+	switch {
+	case outOfRange && !b.outOfRange:
+		b.logger.Warn("Price broke out of grid range", "price", price, "lowerPrice", lower, "upperPrice", upper,
+			"driftPct", drift, "startPrice", b.startPrice)
+	case !outOfRange && b.outOfRange:
+		b.logger.Info("Price is back inside grid range", "price", price, "lowerPrice", lower, "upperPrice", upper)
+	case interval != b.interval:
+		b.logger.Info("Price crossed a grid level", "price", price, "driftPct", drift, "startPrice", b.startPrice)
+	}
+
+	b.lastPrice = price
+	b.lastPriceAt = time.Now()
+	b.interval = interval
+	b.outOfRange = outOfRange
+
+	if reason, action, crossed := b.crossedTrigger(price); crossed && !b.triggered {
+		b.triggered = true
+		return reason, action, true
+	}
+	return "", "", false
 }
 
-// Stop cancels all open orders and stops the bot
-func (b *GridBot) Stop(ctx context.Context) error {
+// CheckOrders queries the status of every tracked order and reacts to fills.
+// The running bot does this every PollInterval while fills are not streamed;
+// simulations that move the market themselves call it after every price
+// change. It does nothing unless the bot is running.
+func (b *GridBot) CheckOrders(ctx context.Context) {
+	if !b.trading() {
+		return
+	}
+	b.checkOrders(ctx)
+}
+
+// trading reports whether the bot is in the running state
+func (b *GridBot) trading() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.state == RunStateRunning
+}
+
+// checkOrders queries the status of every tracked order and reacts to fills
+func (b *GridBot) checkOrders(ctx context.Context) {
+	b.mu.RLock()
+	orderIDs := make([]string, 0, len(b.orders))
+	for orderID := range b.orders {
+		orderIDs = append(orderIDs, orderID)
+	}
+	b.mu.RUnlock()
+
+	for _, orderID := range orderIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		b.checkOrder(ctx, orderID)
+	}
+}
+
+// checkOrder queries the status of a tracked order and reacts to a fill
+func (b *GridBot) checkOrder(ctx context.Context, orderID string) {
+	info, err := b.exchange.GetOrder(ctx, b.config.Symbol, orderID)
+	if err != nil {
+		b.logger.Warn("Failed to get order status", "orderID", orderID, "error", err)
+		return
+	}
+	b.closeOrder(ctx, orderID, info.Status, true)
+}
+
+// closeOrder books a tracked order the exchange reports filled, placing its
+// counter-order if counter is set, and stops tracking one closed without a
+// fill. It reports false if the order is still open.
+func (b *GridBot) closeOrder(ctx context.Context, orderID string, status types.OrderStatus, counter bool) bool {
+	switch status {
+	case types.OrderStatusFilled:
+		// Order status carries no commission; use any reported by partial
+		// fills or else estimate it
+		b.mu.Lock()
+		charges := b.partialFees[orderID]
+		delete(b.partialFees, orderID)
+		delete(b.filled, orderID)
+		b.mu.Unlock()
+		if counter {
+			b.handleFill(ctx, orderID, charges)
+		} else {
+			b.bookFill(ctx, orderID, charges)
+		}
+	case types.OrderStatusCanceled, types.OrderStatusRejected, types.OrderStatusExpired:
+		b.dropOrder(orderID, status)
+	default:
+		return false
+	}
+	return true
+}
+
+// cancelOrder cancels a tracked order and stops tracking it. An order the
+// exchange no longer knows as open was filled or closed before the bot saw
+// it: a fill is booked without placing a counter-order, and cancelOrder
+// reports true.
+func (b *GridBot) cancelOrder(ctx context.Context, orderID string) (bool, error) {
+	err := b.exchange.CancelOrder(ctx, b.config.Symbol, orderID)
+	if err == nil {
+		b.mu.Lock()
+		delete(b.orders, orderID)
+		delete(b.partialFees, orderID)
+		delete(b.filled, orderID)
+		b.mu.Unlock()
+		return false, nil
+	}
+	if !errors.Is(err, types.ErrUnknownOrder) {
+		return false, err
+	}
+
+	info, getErr := b.exchange.GetOrder(ctx, b.config.Symbol, orderID)
+	if getErr != nil {
+		return false, fmt.Errorf("%w, and failed to get its status: %w", err, getErr)
+	}
+	if !b.closeOrder(ctx, orderID, info.Status, false) {
+		return false, fmt.Errorf("%w, but it is %s", err, info.Status)
+	}
+	b.logger.Info("Order closed before it could be canceled", "orderID", orderID, "status", info.Status)
+	return info.Status == types.OrderStatusFilled, nil
+}
+
+// dropOrder stops tracking an order that was closed without being filled
+func (b *GridBot) dropOrder(orderID string, status types.OrderStatus) {
+	b.mu.Lock()
+	order, ok := b.orders[orderID]
+	delete(b.orders, orderID)
+	delete(b.partialFees, orderID)
+	delete(b.filled, orderID)
+	b.mu.Unlock()
+
+	if ok {
+		b.logger.Info("Order closed without filling, no longer tracking it",
+			append(order.logAttrs(orderID), "status", status)...)
+		b.saveState()
+	}
+}
+
+// reportFee returns the commission charged by an execution, if any
+func reportFee(report types.ExecutionReport) []feeCharge {
+	if report.Commission <= 0 || report.CommissionAsset == "" {
+		return nil
+	}
+	return []feeCharge{{asset: report.CommissionAsset, amount: report.Commission}}
+}
+
+// handleFill books a filled order and places its counter-order at the
+// adjacent level. charges are the commissions reported for the order, nil if
+// unknown.
+func (b *GridBot) handleFill(ctx context.Context, orderID string, charges []feeCharge) {
+	filled, baseFee, ok := b.bookFill(ctx, orderID, charges)
+	if !ok {
+		return
+	}
+
+	// A filled buy is sold one level up, a filled sell is bought back one level down.
+	// The sell is sized to the base asset the buy left after commission.
+	level, side, quantity := filled.level+1, "SELL", filled.order.Quantity-baseFee
+	if filled.order.Side == "SELL" {
+		level, side, quantity = filled.level-1, "BUY", filled.order.Quantity
+	}
+	if level < 0 || level >= len(b.levels) {
+		b.logger.Info("No grid level for counter-order", "orderID", orderID, "side", side)
+		return
+	}
+	if b.levelOccupied(level) {
+		b.logger.Info("Grid level already has an order, skipping counter-order",
+			"orderID", orderID, "gridLevel", level, "side", side, "price", b.levels[level])
+		return
+	}
+
+	if err := b.placeOrder(ctx, level, side, quantity); err != nil {
+		b.logger.Error("Failed to place counter-order", "orderID", orderID, "gridLevel", level, "side", side,
+			"price", b.levels[level], "qty", quantity, "error", err)
+	}
+}
+
+// bookFill stops tracking a filled order and books its fill, returning the
+// order and the commission charged in the base asset. It reports false if the
+// order is not tracked.
+func (b *GridBot) bookFill(ctx context.Context, orderID string, charges []feeCharge) (gridOrder, float64, bool) {
 	b.mu.Lock()
-	if !b.running {
+	filled, ok := b.orders[orderID]
+	if !ok {
 		b.mu.Unlock()
-		return fmt.Errorf("bot is not running")
+		return gridOrder{}, 0, false
 	}
-	b.running = false
+	delete(b.orders, orderID)
+	b.fills = append(b.fills, Fill{
+		OrderID:  orderID,
+		Level:    filled.level,
+		Side:     filled.order.Side,
+		Price:    filled.order.Price,
+		Quantity: filled.order.Quantity,
+		Time:     time.Now(),
+	})
+	b.lastFillAt = time.Now()
 	b.mu.Unlock()
+	baseFee := b.recordFill(ctx, filled, charges)
+	b.saveState()
+	b.refreshBalances(ctx)
 
-	// Cancel all open orders
-	var lastError error
-	b.mu.RLock()
-	for orderID, order := range b.orders {
-		if err := b.exchange.CancelOrder(ctx, order.Symbol, orderID); err != nil {
-			log.Printf("Failed to cancel order %s: %v", orderID, err)
-			lastError = err
+	b.logger.Info("Order filled", filled.logAttrs(orderID)...)
+	return filled, baseFee, true
+}
+
+// placeOrder places a limit order at the given grid level and tracks it. If
+// the exchange fails with a retryable error the order is placed again in the
+// background.
+func (b *GridBot) placeOrder(ctx context.Context, level int, side string, quantity float64) error {
+	return b.submitOrder(ctx, level, side, quantity, newClientOrderID(level, side))
+}
+
+// submitOrder places a grid order under clientOrderID and tracks it. An order
+// the exchange rejects as a duplicate was placed by an earlier attempt that
+// failed with an unknown outcome; that order is tracked instead.
+func (b *GridBot) submitOrder(ctx context.Context, level int, side string, quantity float64, clientOrderID string) error {
+	order := b.newOrder(side, b.levels[level], quantity)
+	order.ClientOrderID = clientOrderID
+
+	orderID, err := b.exchange.PlaceOrder(ctx, order)
+	if errors.Is(err, types.ErrDuplicateOrder) {
+		orderID, err = b.findOpenOrder(ctx, clientOrderID, err)
+	}
+	if err != nil {
+		if types.IsRetryable(err) && ctx.Err() == nil {
+			b.scheduleRetry(level, side, quantity, clientOrderID)
+		} else {
+			b.mu.Lock()
+			delete(b.failed, level)
+			b.mu.Unlock()
+		}
+		return err
+	}
+
+	placed := gridOrder{order: order, level: level}
+	b.mu.Lock()
+	b.orders[orderID] = placed
+	delete(b.failed, level)
+	b.mu.Unlock()
+	b.saveState()
+
+	b.logger.Info("Placed order", placed.logAttrs(orderID)...)
+	return nil
+}
+
+// findOpenOrder returns the ID of the open order placed under clientOrderID,
+// or placeErr if there is none
+func (b *GridBot) findOpenOrder(ctx context.Context, clientOrderID string, placeErr error) (string, error) {
+	open, err := b.exchange.GetOpenOrders(ctx, b.config.Symbol)
+	if err != nil {
+		return "", fmt.Errorf("%w, and failed to look it up: %w", placeErr, err)
+	}
+	for _, info := range open {
+		if info.ClientOrderID == clientOrderID {
+			return info.OrderID, nil
+		}
+	}
+	return "", placeErr
+}
+
+// newOrder builds a grid limit order rounded to the symbol's trading rules
+func (b *GridBot) newOrder(side string, price, quantity float64) types.Order {
+	return types.Order{
+		Symbol:      b.config.Symbol,
+		Side:        side,
+		Type:        "LIMIT",
+		Quantity:    grid.FloorToStep(quantity, b.symbolInfo.StepSize),
+		Price:       grid.RoundToTick(price, b.symbolInfo.TickSize),
+		TimeInForce: "GTC",
+	}
+}
+
+// nearestLevel returns the index of the grid level closest to price
+func nearestLevel(levels []float64, price float64) int {
+	nearest := 0
+	for i, level := range levels {
+		if math.Abs(level-price) < math.Abs(levels[nearest]-price) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// levelsBelow returns the number of grid levels at or below price
+func levelsBelow(levels []float64, price float64) int {
+	n := 0
+	for _, level := range levels {
+		if level <= price {
+			n++
 		}
 	}
+	return n
+}
+
+// Stop cancels all open orders and stops a running or paused bot. If some
+// orders cannot be canceled the bot ends up in the error state.
+func (b *GridBot) Stop(ctx context.Context) error {
+	b.lifecycle.Lock()
+	defer b.lifecycle.Unlock()
+
+	b.mu.RLock()
+	state := b.state
 	b.mu.RUnlock()
+	if state != RunStateRunning && state != RunStatePaused {
+		return ErrNotRunning
+	}
+	b.setState(RunStateStopping, nil)
 
-	return lastError
+	err := b.halt(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to cancel all orders: %w", err)
+	}
+	b.markStopped(StopReasonManual, err)
+	return err
 }
 
-// GetStatus returns the current status of the grid bot
-func (b *GridBot) GetStatus() map[string]interface{} {
+// stopLoop stops the watch loop, if any, and waits for it to exit so that no
+// fill or price update is handled until the next launch
+func (b *GridBot) stopLoop() {
+	b.mu.Lock()
+	cancel, done := b.cancel, b.done
+	b.cancel, b.done = nil, nil
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// halt stops the watch loop and cancels all open orders. It returns the last
+// cancellation error; orders that could not be canceled remain tracked.
+func (b *GridBot) halt(ctx context.Context) error {
+	// Wait for the watch loop to exit so no new orders are placed
+	b.stopLoop()
+
+	// Cancel all open orders
 	b.mu.RLock()
-	defer b.mu.RUnlock()
+	orders := make(map[string]gridOrder, len(b.orders))
+	for orderID, order := range b.orders {
+		orders[orderID] = order
+	}
+	b.mu.RUnlock()
 
-	return map[string]interface{}{
-		"running":    b.running,
-		"symbol":     b.config.Symbol,
-		"lowerPrice": b.config.LowerPrice,
-		"upperPrice": b.config.UpperPrice,
-		"gridNum":    b.config.GridNum,
-		"investment": b.config.Investment,
-		"openOrders": len(b.orders),
+	var lastError error
+	for orderID, order := range orders {
+		if _, err := b.cancelOrder(ctx, orderID); err != nil {
+			b.logger.Error("Failed to cancel order", append(order.logAttrs(orderID), "error", err)...)
+			lastError = err
+		}
 	}
+	b.saveState()
+
+	return lastError
 }
@@ -1,24 +1,38 @@
 package bot
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/grid"
 	"spot_grid_bot/pkg/types"
 )
 
 type mockExchange struct {
+	mu           sync.Mutex
 	currentPrice float64
 	orders       map[string]mockOrder
-	orderCounter int // Added to generate unique order IDs
+	filled       map[string]mockOrder // Orders that have been filled
+	orderCounter int                  // Added to generate unique order IDs
 }
 
 type mockOrder struct {
-	symbol   string
-	side     string
-	price    float64
-	quantity float64
-	orderID  string
+	symbol        string
+	side          string
+	price         float64
+	quantity      float64
+	orderID       string
+	clientOrderID string
+	executed      float64 // Quantity filled while open
 }
 
 func (m *mockExchange) GetSymbolPrice(ctx context.Context, symbol string) (float64, error) {
@@ -26,24 +40,107 @@ func (m *mockExchange) GetSymbolPrice(ctx context.Context, symbol string) (float
 }
 
 func (m *mockExchange) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.orderCounter++
 	orderID := "test_order_" + string(rune(m.orderCounter+'0'))
 
 	m.orders[orderID] = mockOrder{
-		symbol:   order.Symbol,
-		side:     order.Side,
-		price:    order.Price,
-		quantity: order.Quantity,
-		orderID:  orderID,
+		symbol:        order.Symbol,
+		side:          order.Side,
+		price:         order.Price,
+		quantity:      order.Quantity,
+		orderID:       orderID,
+		clientOrderID: order.ClientOrderID,
 	}
 	return orderID, nil
 }
 
 func (m *mockExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	delete(m.orders, orderID)
 	return nil
 }
 
+func (m *mockExchange) GetOrder(ctx context.Context, symbol, orderID string) (types.OrderInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if order, ok := m.orders[orderID]; ok {
+		return m.orderInfo(order, types.OrderStatusNew), nil
+	}
+	if order, ok := m.filled[orderID]; ok {
+		return m.orderInfo(order, types.OrderStatusFilled), nil
+	}
+	return types.OrderInfo{OrderID: orderID, Symbol: symbol, Status: types.OrderStatusCanceled}, nil
+}
+
+func (m *mockExchange) GetOpenOrders(ctx context.Context, symbol string) ([]types.OrderInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []types.OrderInfo
+	for _, order := range m.orders {
+		if order.symbol == symbol {
+			infos = append(infos, m.orderInfo(order, types.OrderStatusNew))
+		}
+	}
+	return infos, nil
+}
+
+func (m *mockExchange) orderInfo(order mockOrder, status types.OrderStatus) types.OrderInfo {
+	info := types.OrderInfo{
+		OrderID:          order.orderID,
+		ClientOrderID:    order.clientOrderID,
+		Symbol:           order.symbol,
+		Side:             order.side,
+		Price:            order.price,
+		Quantity:         order.quantity,
+		ExecutedQuantity: order.executed,
+		Status:           status,
+	}
+	if status == types.OrderStatusFilled {
+		info.ExecutedQuantity = order.quantity
+	}
+	return info
+}
+
+// fill marks the open order at the given price and side as filled
+func (m *mockExchange) fill(t *testing.T, side string, price float64) mockOrder {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for orderID, order := range m.orders {
+		if order.side == side && order.price == price {
+			if m.filled == nil {
+				m.filled = make(map[string]mockOrder)
+			}
+			m.filled[orderID] = order
+			delete(m.orders, orderID)
+			return order
+		}
+	}
+	t.Fatalf("no open %s order at price %.2f", side, price)
+	return mockOrder{}
+}
+
+// openOrder returns the open order at the given price and side, if any
+func (m *mockExchange) openOrder(side string, price float64) (mockOrder, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, order := range m.orders {
+		if order.side == side && order.price == price {
+			return order, true
+		}
+	}
+	return mockOrder{}, false
+}
+
 func (m *mockExchange) GetBalance(ctx context.Context, asset string) (float64, error) {
 	return 1000.0, nil // Mock balance for testing
 }
@@ -87,6 +184,112 @@ func TestNewGridBot(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Poll interval too short",
+			config: GridBotConfig{
+				Symbol:       "BTCUSDT",
+				LowerPrice:   25000.0,
+				UpperPrice:   35000.0,
+				GridNum:      5,
+				Investment:   1000.0,
+				PollInterval: time.Nanosecond,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Geometric spacing",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 25000.0,
+				UpperPrice: 35000.0,
+				GridNum:    5,
+				Investment: 1000.0,
+				Spacing:    grid.SpacingGeometric,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Explicit levels",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				Investment: 1000.0,
+				Spacing:    grid.SpacingExplicit,
+				Levels:     []float64{26000, 28000, 29500, 30500, 34000},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Explicit spacing without levels",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				Investment: 1000.0,
+				Spacing:    grid.SpacingExplicit,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Weighted spacing",
+			config: GridBotConfig{
+				Symbol:      "BTCUSDT",
+				LowerPrice:  25000.0,
+				UpperPrice:  35000.0,
+				GridNum:     7,
+				Investment:  1000.0,
+				Spacing:     grid.SpacingWeighted,
+				CenterPrice: 31000.0,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Weighted center outside range",
+			config: GridBotConfig{
+				Symbol:      "BTCUSDT",
+				LowerPrice:  25000.0,
+				UpperPrice:  35000.0,
+				GridNum:     7,
+				Investment:  1000.0,
+				Spacing:     grid.SpacingWeighted,
+				CenterPrice: 40000.0,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Martingale sizing",
+			config: GridBotConfig{
+				Symbol:         "BTCUSDT",
+				LowerPrice:     25000.0,
+				UpperPrice:     35000.0,
+				GridNum:        5,
+				Investment:     1000.0,
+				Sizing:         grid.SizingMartingale,
+				SizeMultiplier: 2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unknown sizing",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 25000.0,
+				UpperPrice: 35000.0,
+				GridNum:    5,
+				Investment: 1000.0,
+				Sizing:     "all-in",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown spacing",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 25000.0,
+				UpperPrice: 35000.0,
+				GridNum:    5,
+				Investment: 1000.0,
+				Spacing:    "fibonacci",
+			},
+			wantErr: true,
+		},
 		{
 			name: "Invalid investment amount",
 			config: GridBotConfig{
@@ -120,6 +323,153 @@ func TestNewGridBot(t *testing.T) {
 	}
 }
 
+func TestGridBotLevelGenerator(t *testing.T) {
+	exchange := &mockExchange{
+		currentPrice: 30000.0,
+		orders:       make(map[string]mockOrder),
+	}
+	config := GridBotConfig{
+		Symbol:     "BTCUSDT",
+		Investment: 1000.0,
+	}
+	generator := grid.ExplicitGenerator{Prices: []float64{32000, 28000, 30500}}
+
+	bot, err := NewGridBot(exchange, config, WithLevelGenerator(generator))
+	if err != nil {
+		t.Fatalf("NewGridBot() error = %v", err)
+	}
+
+	want := []float64{28000, 30500, 32000}
+	if !slices.Equal(bot.levels, want) {
+		t.Errorf("levels = %v, want %v", bot.levels, want)
+	}
+	if bot.config.LowerPrice != 28000 || bot.config.UpperPrice != 32000 || bot.config.GridNum != 3 {
+		t.Errorf("config bounds = %v-%v x%d, want 28000-32000 x3",
+			bot.config.LowerPrice, bot.config.UpperPrice, bot.config.GridNum)
+	}
+}
+
+func TestGridBotGridProfit(t *testing.T) {
+	fees := grid.Fees{MakerRate: 0.001, TakerRate: 0.001}
+
+	tests := []struct {
+		name      string
+		gridNum   int
+		minProfit float64
+		policy    grid.ProfitPolicy
+		wantErr   bool
+	}{
+		// 2500 apart nets over 7% per round trip
+		{name: "Profitable", gridNum: 5},
+		// About 50 apart, which the 0.2% round trip fees outweigh
+		{name: "Fees exceed spacing", gridNum: 201, wantErr: true},
+		{name: "Below minimum", gridNum: 5, minProfit: 0.1, wantErr: true},
+		{name: "Warn only", gridNum: 201, policy: grid.ProfitPolicyWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := &mockExchange{
+				currentPrice: 30000.0,
+				orders:       make(map[string]mockOrder),
+			}
+			config := GridBotConfig{
+				Symbol:        "BTCUSDT",
+				LowerPrice:    25000.0,
+				UpperPrice:    35000.0,
+				GridNum:       tt.gridNum,
+				Investment:    100000.0,
+				Fees:          fees,
+				MinGridProfit: tt.minProfit,
+				ProfitPolicy:  tt.policy,
+			}
+
+			_, err := NewGridBot(exchange, config)
+			var unprofitable *grid.UnprofitableGridError
+			if tt.wantErr && !errors.As(err, &unprofitable) {
+				t.Errorf("Expected *grid.UnprofitableGridError, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("NewGridBot() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGridBotPositionSizing(t *testing.T) {
+	tests := []struct {
+		name   string
+		sizing grid.Sizing
+		check  func(t *testing.T, levels, quantities []float64)
+	}{
+		{
+			name:   "Equal quote",
+			sizing: grid.SizingEqualQuote,
+			check: func(t *testing.T, levels, quantities []float64) {
+				for i := range levels {
+					if quote := levels[i] * quantities[i]; math.Abs(quote-200.0) > 1e-9 {
+						t.Errorf("Level %v: quote amount = %v, want 200", levels[i], quote)
+					}
+				}
+			},
+		},
+		{
+			name:   "Equal base",
+			sizing: grid.SizingEqualBase,
+			check: func(t *testing.T, levels, quantities []float64) {
+				for i := range quantities {
+					if math.Abs(quantities[i]-quantities[0]) > 1e-12 {
+						t.Errorf("Level %v: quantity = %v, want %v", levels[i], quantities[i], quantities[0])
+					}
+				}
+			},
+		},
+		{
+			name:   "Martingale",
+			sizing: grid.SizingMartingale,
+			check: func(t *testing.T, levels, quantities []float64) {
+				if !(levels[0]*quantities[0] > levels[1]*quantities[1] && levels[1]*quantities[1] > levels[2]*quantities[2]) {
+					t.Errorf("Expected quote amounts to grow toward the lower edge, got quantities %v", quantities)
+				}
+				if !(levels[4]*quantities[4] > levels[3]*quantities[3] && levels[3]*quantities[3] > levels[2]*quantities[2]) {
+					t.Errorf("Expected quote amounts to grow toward the upper edge, got quantities %v", quantities)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := &mockExchange{
+				currentPrice: 30000.0,
+				orders:       make(map[string]mockOrder),
+			}
+			config := GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 25000.0,
+				UpperPrice: 35000.0,
+				GridNum:    5,
+				Investment: 1000.0,
+				Sizing:     tt.sizing,
+			}
+
+			bot, err := NewGridBot(exchange, config)
+			if err != nil {
+				t.Fatalf("NewGridBot() error = %v", err)
+			}
+
+			var total float64
+			for i := range bot.levels {
+				total += bot.levels[i] * bot.levelQuantity(i)
+			}
+			if total > config.Investment+1e-9 {
+				t.Errorf("Grid orders are worth %v, more than the investment %v", total, config.Investment)
+			}
+			tt.check(t, bot.levels, bot.quantities)
+		})
+	}
+}
+
 func TestGridBotInitialOrders(t *testing.T) {
 	config := GridBotConfig{
 		Symbol:     "BTCUSDT",
@@ -181,3 +531,564 @@ func TestGridBotInitialOrders(t *testing.T) {
 		t.Errorf("Expected all orders to be cancelled, but %d orders remain", len(exchange.orders))
 	}
 }
+
+func TestGridBotFillReplacement(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour, // Fills are checked manually below
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	// The level closest to the current price is left empty
+	if _, ok := exchange.openOrder("BUY", 30000.0); ok {
+		t.Fatal("Expected no order at the level closest to the current price")
+	}
+
+	tests := []struct {
+		name      string
+		side      string
+		price     float64
+		wantSide  string
+		wantPrice float64
+	}{
+		{
+			name:      "Filled buy places sell one level up",
+			side:      "BUY",
+			price:     27500.0,
+			wantSide:  "SELL",
+			wantPrice: 30000.0,
+		},
+		{
+			name:      "Filled counter sell places buy one level down",
+			side:      "SELL",
+			price:     30000.0,
+			wantSide:  "BUY",
+			wantPrice: 27500.0,
+		},
+		{
+			name:      "Filled initial sell places buy one level down",
+			side:      "SELL",
+			price:     32500.0,
+			wantSide:  "BUY",
+			wantPrice: 30000.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filled := exchange.fill(t, tt.side, tt.price)
+			bot.checkOrders(ctx)
+
+			order, ok := exchange.openOrder(tt.wantSide, tt.wantPrice)
+			if !ok {
+				t.Fatalf("Expected %s order at %.2f", tt.wantSide, tt.wantPrice)
+			}
+			if order.quantity != filled.quantity {
+				t.Errorf("Counter-order quantity = %.8f, want %.8f", order.quantity, filled.quantity)
+			}
+		})
+	}
+
+	if got := bot.GetStatus().OpenOrders; got != 4 {
+		t.Errorf("Expected 4 tracked orders, got %v", got)
+	}
+}
+
+func TestGridBotDropsCanceledOrders(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 30000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	// Cancel an order behind the bot's back
+	order, ok := exchange.openOrder("BUY", 25000.0)
+	if !ok {
+		t.Fatal("Expected a buy order at 25000")
+	}
+	exchange.CancelOrder(ctx, order.symbol, order.orderID)
+
+	bot.checkOrders(ctx)
+
+	if got, want := bot.GetStatus().OpenOrders, 3; got != want {
+		t.Errorf("Expected %d tracked orders, got %v", want, got)
+	}
+	if len(exchange.orders) != 3 {
+		t.Errorf("Expected no new orders to be placed, exchange has %d", len(exchange.orders))
+	}
+}
+
+// streamingExchange is a mockExchange that pushes execution reports
+type streamingExchange struct {
+	*mockExchange
+	reports chan types.ExecutionReport
+}
+
+func (s *streamingExchange) SubscribeExecutionReports(ctx context.Context) (<-chan types.ExecutionReport, error) {
+	return s.reports, nil
+}
+
+func TestGridBotReactsToExecutionReports(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour, // Only the stream can trigger the counter-order
+	}
+
+	exchange := &streamingExchange{
+		mockExchange: &mockExchange{
+			currentPrice: 30000.0,
+			orders:       make(map[string]mockOrder),
+		},
+		reports: make(chan types.ExecutionReport),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	filled := exchange.fill(t, "BUY", 27500.0)
+	exchange.reports <- types.ExecutionReport{
+		Symbol:             filled.symbol,
+		OrderID:            filled.orderID,
+		Side:               filled.side,
+		Status:             types.OrderStatusFilled,
+		Price:              filled.price,
+		Quantity:           filled.quantity,
+		CumulativeQuantity: filled.quantity,
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := exchange.openOrder("SELL", 30000.0); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for counter-order after execution report")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// monitoredExchange is a streamingExchange that reports whether its stream is
+// connected and counts order status lookups
+type monitoredExchange struct {
+	*streamingExchange
+	mu             sync.Mutex
+	connectedSince time.Time
+	lookups        int
+}
+
+func (m *monitoredExchange) ExecutionReportsConnectedSince() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connectedSince
+}
+
+func (m *monitoredExchange) GetOrder(ctx context.Context, symbol, orderID string) (types.OrderInfo, error) {
+	m.mu.Lock()
+	m.lookups++
+	m.mu.Unlock()
+	return m.streamingExchange.GetOrder(ctx, symbol, orderID)
+}
+
+func TestGridBotPollsOnlyWithoutStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		connected   bool
+		wantLookups bool
+	}{
+		{name: "Stream connected", connected: true, wantLookups: false},
+		{name: "Stream down", connected: false, wantLookups: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GridBotConfig{
+				Symbol:       "BTCUSDT",
+				LowerPrice:   25000.0,
+				UpperPrice:   35000.0,
+				GridNum:      5,
+				Investment:   1000.0,
+				PollInterval: time.Hour,
+			}
+
+			exchange := &monitoredExchange{
+				streamingExchange: &streamingExchange{
+					mockExchange: &mockExchange{
+						currentPrice: 30000.0,
+						orders:       make(map[string]mockOrder),
+					},
+					reports: make(chan types.ExecutionReport),
+				},
+			}
+			if tt.connected {
+				exchange.connectedSince = time.Now().Add(-time.Minute)
+			}
+
+			bot, err := NewGridBot(exchange, config)
+			if err != nil {
+				t.Fatalf("Failed to create bot: %v", err)
+			}
+			// Tick faster than the configuration allows
+			bot.config.PollInterval = 10 * time.Millisecond
+
+			ctx := context.Background()
+			if err := bot.Start(ctx); err != nil {
+				t.Fatalf("Failed to start bot: %v", err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			bot.Stop(ctx)
+
+			exchange.mu.Lock()
+			lookups := exchange.lookups
+			exchange.mu.Unlock()
+			if (lookups > 0) != tt.wantLookups {
+				t.Errorf("Order status lookups during ticks = %d, want any: %v", lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+// priceStreamingExchange is a mockExchange that pushes live prices
+type priceStreamingExchange struct {
+	*mockExchange
+	prices chan types.PriceUpdate
+}
+
+func (p *priceStreamingExchange) SubscribePrice(ctx context.Context, symbol string) (<-chan types.PriceUpdate, error) {
+	return p.prices, nil
+}
+
+func TestGridBotTracksLivePrice(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &priceStreamingExchange{
+		mockExchange: &mockExchange{
+			currentPrice: 30000.0,
+			orders:       make(map[string]mockOrder),
+		},
+		prices: make(chan types.PriceUpdate),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	tests := []struct {
+		name           string
+		price          float64
+		wantOutOfRange bool
+	}{
+		{name: "Inside range", price: 31000.0, wantOutOfRange: false},
+		{name: "Breakout above", price: 36000.0, wantOutOfRange: true},
+		{name: "Back inside range", price: 34000.0, wantOutOfRange: false},
+		{name: "Breakout below", price: 24000.0, wantOutOfRange: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange.prices <- types.PriceUpdate{Symbol: config.Symbol, Price: tt.price}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				status := bot.GetStatus()
+				if status.LastPrice == tt.price {
+					if status.OutOfRange != tt.wantOutOfRange {
+						t.Errorf("outOfRange = %v, want %v", status.OutOfRange, tt.wantOutOfRange)
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("Timed out waiting for price %.2f", tt.price)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+// infoExchange is a mockExchange that provides symbol trading rules
+type infoExchange struct {
+	*mockExchange
+	info types.SymbolInfo
+}
+
+func (e *infoExchange) GetSymbolInfo(ctx context.Context, symbol string) (types.SymbolInfo, error) {
+	return e.info, nil
+}
+
+func TestGridBotSymbolFilters(t *testing.T) {
+	info := types.SymbolInfo{
+		Symbol:      "BTCUSDT",
+		BaseAsset:   "BTC",
+		QuoteAsset:  "USDT",
+		TickSize:    0.01,
+		StepSize:    0.00001,
+		MinQty:      0.00001,
+		MinNotional: 5,
+	}
+
+	tests := []struct {
+		name       string
+		config     GridBotConfig
+		wantLevels []float64
+		wantErr    bool
+	}{
+		{
+			name: "Levels rounded to tick size",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 100.0,
+				UpperPrice: 200.0,
+				GridNum:    4,
+				Investment: 1000.0,
+			},
+			wantLevels: []float64{100.0, 133.33, 166.67, 200.0},
+		},
+		{
+			name: "Per-level notional below minimum",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 25000.0,
+				UpperPrice: 35000.0,
+				GridNum:    5,
+				Investment: 20.0, // 4 USDT per level
+			},
+			wantErr: true,
+		},
+		{
+			name: "Levels closer than tick size",
+			config: GridBotConfig{
+				Symbol:     "BTCUSDT",
+				LowerPrice: 100.0,
+				UpperPrice: 100.02,
+				GridNum:    5,
+				Investment: 1000.0,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := &infoExchange{
+				mockExchange: &mockExchange{
+					currentPrice: 150.0,
+					orders:       make(map[string]mockOrder),
+				},
+				info: info,
+			}
+
+			bot, err := NewGridBot(exchange, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGridBot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, want := range tt.wantLevels {
+				if bot.levels[i] != want {
+					t.Errorf("Level %d: expected %v, got %v", i, want, bot.levels[i])
+				}
+			}
+			for i := range bot.levels {
+				if q := bot.levelQuantity(i); q != grid.FloorToStep(q, info.StepSize) {
+					t.Errorf("Level %d quantity %v is not a multiple of the step size", i, q)
+				}
+			}
+		})
+	}
+}
+
+func TestGridBotPaperTrading(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour, // Fills arrive as execution reports only
+	}
+
+	paper, err := exchange.NewPaperExchange(exchange.SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 1000, "BTC": 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create paper exchange: %v", err)
+	}
+	paper.SetPrice(types.PriceUpdate{Price: 30000.0})
+
+	bot, err := NewGridBot(paper, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	waitForOrder := func(side string, price float64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			orders, err := paper.GetOpenOrders(ctx, config.Symbol)
+			if err != nil {
+				t.Fatalf("GetOpenOrders() error = %v", err)
+			}
+			for _, order := range orders {
+				if order.Side == side && order.Price == price {
+					return
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s order at %.2f", side, price)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The price dips through the 27500 buy, which is sold one level up
+	paper.SetPrice(types.PriceUpdate{Price: 27000.0})
+	waitForOrder("SELL", 30000.0)
+
+	// The price recovers through the counter-sell, which is bought back
+	paper.SetPrice(types.PriceUpdate{Price: 30500.0})
+	waitForOrder("BUY", 27500.0)
+
+	status := bot.GetStatus()
+	if status.Fills != 2 {
+		t.Errorf("Expected 2 fills, got %v", status.Fills)
+	}
+	want := (30000.0 - 27500.0) * (200.0 / 27500.0)
+	if got := status.PnL.RealizedPnL; math.Abs(got-want) > 1e-9 {
+		t.Errorf("realizedPnl = %v, want %v", got, want)
+	}
+}
+
+func TestGridBotStructuredLogs(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	bot, err := NewGridBot(exchange, config, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	filled := exchange.fill(t, "BUY", 27500.0)
+	bot.checkOrders(ctx)
+
+	var record map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var r map[string]any
+		if err := json.Unmarshal(line, &r); err != nil {
+			t.Fatalf("Invalid JSON log line %q: %v", line, err)
+		}
+		if r["msg"] == "Order filled" {
+			record = r
+		}
+	}
+	if record == nil {
+		t.Fatalf("No fill logged in:\n%s", buf.String())
+	}
+
+	want := map[string]any{
+		"level":     "INFO",
+		"symbol":    "BTCUSDT",
+		"orderID":   filled.orderID,
+		"gridLevel": 1.0,
+		"side":      "BUY",
+		"price":     27500.0,
+		"qty":       filled.quantity,
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Log field %s = %v, want %v", key, record[key], value)
+		}
+	}
+}
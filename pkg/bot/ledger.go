@@ -0,0 +1,304 @@
+package bot
+
+import (
+	"context"
+	"math"
+)
+
+// Ledger accounts for the profit and loss of the grid's trades. Every sell is
+// paired with a buy filled one grid level below; sells of base asset the grid
+// started with are costed at the price it was valued at on start.
+type Ledger struct {
+	RealizedPnL   float64            `json:"realizedPnl"`             // Profit of sold inventory in quote currency, before fees
+	RoundTrips    int                `json:"roundTrips"`              // Sells paired with a grid buy one level below
+	Fees          float64            `json:"fees"`                    // Fees paid, converted to quote currency
+	FeesByAsset   map[string]float64 `json:"feesByAsset,omitempty"`   // Fees paid, in the asset they were charged in
+	EstimatedFees float64            `json:"estimatedFees,omitempty"` // Part of Fees estimated from the fee rates because the exchange did not report them
+	Inventory     float64            `json:"inventory"`               // Base asset held for the grid
+	CostBasis     float64            `json:"costBasis"`               // Quote currency paid for Inventory
+	Lots          []Lot              `json:"lots,omitempty"`          // Grid buys not yet sold, oldest first
+}
+
+// Lot is base asset bought by a grid order and not yet sold
+type Lot struct {
+	Level    int     `json:"level"`
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// PnL summarizes the ledger at a price
+type PnL struct {
+	RealizedPnL   float64            `json:"realizedPnl"`
+	UnrealizedPnL float64            `json:"unrealizedPnl"` // Inventory valued at Price minus its cost basis
+	Fees          float64            `json:"fees"`
+	NetPnL        float64            `json:"netPnl"` // Realized plus unrealized, after fees
+	FeesByAsset   map[string]float64 `json:"feesByAsset,omitempty"`
+	EstimatedFees float64            `json:"estimatedFees,omitempty"`
+	RoundTrips    int                `json:"roundTrips"`
+	Inventory     float64            `json:"inventory"`
+	AverageCost   float64            `json:"averageCost"` // Average price paid for the inventory
+	Price         float64            `json:"price"`       // Price the inventory is valued at
+}
+
+// feeCharge is a commission charged on an execution
+type feeCharge struct {
+	asset  string
+	amount float64
+}
+
+// dustQuantity is the inventory below which quantities are treated as zero
+const dustQuantity = 1e-12
+
+// open records base asset the grid starts with, valued at price
+func (l *Ledger) open(quantity, price float64) {
+	l.Inventory += quantity
+	l.CostBasis += quantity * price
+}
+
+// buy records a grid buy at a level. baseFee is commission deducted from
+// the base asset received.
+func (l *Ledger) buy(level int, price, quantity, baseFee float64) {
+	quantity -= baseFee
+	if quantity <= 0 {
+		return
+	}
+	l.Lots = append(l.Lots, Lot{Level: level, Price: price, Quantity: quantity})
+	l.Inventory += quantity
+	l.CostBasis += price * quantity
+}
+
+// sell records a grid sell at a level and realizes its profit. The quantity
+// is taken from lots bought one level below first, then from the base asset
+// the grid started with, then from any other lots. Base asset the ledger
+// never saw is costed at the level below, or the sell price at the bottom.
+func (l *Ledger) sell(level int, price, quantity float64, levels []float64) {
+	remaining := quantity
+
+	// Pair with buys one level below
+	paired := false
+	for i := 0; i < len(l.Lots) && remaining > dustQuantity; i++ {
+		if l.Lots[i].Level != level-1 {
+			continue
+		}
+		remaining -= l.takeLot(i, price, remaining)
+		paired = true
+		if l.Lots[i].Quantity <= dustQuantity {
+			l.Lots = append(l.Lots[:i], l.Lots[i+1:]...)
+			i--
+		}
+	}
+	if paired {
+		l.RoundTrips++
+	}
+
+	// Base asset held since the start, at its average cost
+	if unlotted, cost := l.unlotted(); remaining > dustQuantity && unlotted > dustQuantity {
+		taken := math.Min(remaining, unlotted)
+		avg := cost / unlotted
+		l.RealizedPnL += (price - avg) * taken
+		l.Inventory -= taken
+		l.CostBasis -= avg * taken
+		remaining -= taken
+	}
+
+	// Any other lots, oldest first
+	for len(l.Lots) > 0 && remaining > dustQuantity {
+		remaining -= l.takeLot(0, price, remaining)
+		if l.Lots[0].Quantity <= dustQuantity {
+			l.Lots = l.Lots[1:]
+		}
+	}
+
+	if remaining > dustQuantity {
+		cost := price
+		if level > 0 && level <= len(levels) {
+			cost = levels[level-1]
+		}
+		l.RealizedPnL += (price - cost) * remaining
+	}
+	if l.Inventory < dustQuantity {
+		l.Inventory, l.CostBasis = 0, 0
+	}
+}
+
+// shrink removes quantity from the inventory at its average cost, for
+// commission taken from the base asset
+func (l *Ledger) shrink(quantity float64) {
+	if quantity <= 0 || l.Inventory <= 0 {
+		return
+	}
+	quantity = math.Min(quantity, l.Inventory)
+	l.CostBasis -= l.CostBasis / l.Inventory * quantity
+	l.Inventory -= quantity
+}
+
+// liquidate sells quantity of the inventory outside the grid at price,
+// costed at the inventory's average cost. Lots are consumed oldest first.
+func (l *Ledger) liquidate(price, quantity float64) {
+	if quantity <= 0 || l.Inventory <= 0 {
+		return
+	}
+	quantity = math.Min(quantity, l.Inventory)
+	avg := l.CostBasis / l.Inventory
+	l.RealizedPnL += (price - avg) * quantity
+	l.shrink(quantity)
+
+	for remaining := quantity; len(l.Lots) > 0 && remaining > dustQuantity; {
+		taken := math.Min(remaining, l.Lots[0].Quantity)
+		l.Lots[0].Quantity -= taken
+		remaining -= taken
+		if l.Lots[0].Quantity <= dustQuantity {
+			l.Lots = l.Lots[1:]
+		}
+	}
+	if l.Inventory < dustQuantity {
+		l.Inventory, l.CostBasis, l.Lots = 0, 0, nil
+	}
+}
+
+// takeLot sells up to quantity from lot i at price and returns the quantity
+// taken
+func (l *Ledger) takeLot(i int, price, quantity float64) float64 {
+	lot := &l.Lots[i]
+	taken := math.Min(quantity, lot.Quantity)
+	l.RealizedPnL += (price - lot.Price) * taken
+	l.Inventory -= taken
+	l.CostBasis -= lot.Price * taken
+	lot.Quantity -= taken
+	return taken
+}
+
+// unlotted returns the inventory and cost basis not held in lots
+func (l *Ledger) unlotted() (quantity, cost float64) {
+	quantity, cost = l.Inventory, l.CostBasis
+	for _, lot := range l.Lots {
+		quantity -= lot.Quantity
+		cost -= lot.Price * lot.Quantity
+	}
+	return quantity, cost
+}
+
+// addFee records a fee charged in asset and worth value in quote currency. A
+// negative value means the fee could not be converted.
+func (l *Ledger) addFee(asset string, amount, value float64) {
+	if l.FeesByAsset == nil {
+		l.FeesByAsset = make(map[string]float64)
+	}
+	l.FeesByAsset[asset] += amount
+	if value > 0 {
+		l.Fees += value
+	}
+}
+
+// addEstimatedFee records a fee in quote currency that the exchange did not
+// report
+func (l *Ledger) addEstimatedFee(value float64) {
+	l.Fees += value
+	l.EstimatedFees += value
+}
+
+// pnl summarizes the ledger with the inventory valued at price
+func (l *Ledger) pnl(price float64) PnL {
+	p := PnL{
+		RealizedPnL:   l.RealizedPnL,
+		Fees:          l.Fees,
+		EstimatedFees: l.EstimatedFees,
+		RoundTrips:    l.RoundTrips,
+		Inventory:     l.Inventory,
+		Price:         price,
+	}
+	if l.Inventory > 0 {
+		p.AverageCost = l.CostBasis / l.Inventory
+		p.UnrealizedPnL = l.Inventory*price - l.CostBasis
+	}
+	p.NetPnL = p.RealizedPnL + p.UnrealizedPnL - p.Fees
+	if len(l.FeesByAsset) > 0 {
+		p.FeesByAsset = make(map[string]float64, len(l.FeesByAsset))
+		for asset, amount := range l.FeesByAsset {
+			p.FeesByAsset[asset] = amount
+		}
+	}
+	return p
+}
+
+// clone returns a deep copy of the ledger
+func (l *Ledger) clone() *Ledger {
+	c := *l
+	c.Lots = append([]Lot(nil), l.Lots...)
+	if l.FeesByAsset != nil {
+		c.FeesByAsset = make(map[string]float64, len(l.FeesByAsset))
+		for asset, amount := range l.FeesByAsset {
+			c.FeesByAsset[asset] = amount
+		}
+	}
+	return &c
+}
+
+// PnL returns the bot's profit and loss with the inventory valued at the
+// latest known price
+func (b *GridBot) PnL() PnL {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.ledger.pnl(b.lastPrice)
+}
+
+// recordFill books a filled grid order in the ledger. charges are the
+// commissions the exchange reported for the order; without them the fee is
+// estimated from the configured maker rate. It returns the commission charged,
+// or estimated, in the base asset.
+func (b *GridBot) recordFill(ctx context.Context, filled gridOrder, charges []feeCharge) float64 {
+	price, quantity := filled.order.Price, filled.order.Quantity
+	base, quote, _ := b.assets()
+
+	// Convert fees to quote outside the lock; this may query the exchange
+	type convertedFee struct {
+		feeCharge
+		value float64
+	}
+	converted := make([]convertedFee, 0, len(charges))
+	var baseFee float64
+	for _, charge := range charges {
+		value := -1.0
+		switch charge.asset {
+		case quote:
+			value = charge.amount
+		case base:
+			value = charge.amount * price
+			baseFee += charge.amount
+		default:
+			if rate, err := b.exchange.GetSymbolPrice(ctx, charge.asset+quote); err == nil {
+				value = charge.amount * rate
+			} else {
+				b.logger.Warn("Failed to convert fee to quote asset", "clientOrderID", filled.order.ClientOrderID,
+					"gridLevel", filled.level, "side", filled.order.Side, "feeAsset", charge.asset,
+					"fee", charge.amount, "quoteAsset", quote, "error", err)
+			}
+		}
+		converted = append(converted, convertedFee{feeCharge: charge, value: value})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if charges == nil {
+		fee := quantity * b.config.Fees.Maker()
+		b.ledger.addEstimatedFee(price * fee)
+		if filled.order.Side == "BUY" && b.config.Fees.BNBDiscount == 0 {
+			// Unless paid in BNB, a buy's commission comes out of the base
+			// asset received
+			baseFee = fee
+		}
+	}
+	for _, fee := range converted {
+		b.ledger.addFee(fee.asset, fee.amount, fee.value)
+	}
+
+	if filled.order.Side == "BUY" {
+		b.ledger.buy(filled.level, price, quantity, baseFee)
+		return baseFee
+	}
+	b.ledger.sell(filled.level, price, quantity, b.levels)
+	b.ledger.shrink(baseFee)
+	return baseFee
+}
@@ -0,0 +1,261 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+)
+
+func assertClose(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	levels := []float64{25000, 27500, 30000, 32500, 35000}
+	var ledger Ledger
+
+	// Start with 0.02 base valued at 30000
+	ledger.open(0.02, 30000)
+
+	// Buy at 27500, then sell one level up: a paired round trip
+	ledger.buy(1, 27500, 0.01, 0)
+	ledger.sell(2, 30000, 0.01, levels)
+	assertClose(t, "RealizedPnL after round trip", ledger.RealizedPnL, 25)
+	if ledger.RoundTrips != 1 || len(ledger.Lots) != 0 {
+		t.Errorf("Expected 1 round trip and no lots, got %d and %v", ledger.RoundTrips, ledger.Lots)
+	}
+
+	// Sell opening inventory at 32500, costed at 30000
+	ledger.sell(3, 32500, 0.01, levels)
+	assertClose(t, "RealizedPnL after opening sell", ledger.RealizedPnL, 50)
+	if ledger.RoundTrips != 1 {
+		t.Errorf("Expected opening inventory sales not to count as round trips, got %d", ledger.RoundTrips)
+	}
+
+	// Buy at 25000 and value the inventory at 26000
+	ledger.buy(0, 25000, 0.01, 0)
+	pnl := ledger.pnl(26000)
+	assertClose(t, "Inventory", pnl.Inventory, 0.02)
+	assertClose(t, "AverageCost", pnl.AverageCost, 27500)
+	assertClose(t, "UnrealizedPnL", pnl.UnrealizedPnL, 0.02*26000-(0.01*30000+0.01*25000))
+
+	ledger.addFee("USDT", 0.5, 0.5)
+	ledger.addEstimatedFee(0.25)
+	pnl = ledger.pnl(26000)
+	assertClose(t, "Fees", pnl.Fees, 0.75)
+	assertClose(t, "NetPnL", pnl.NetPnL, pnl.RealizedPnL+pnl.UnrealizedPnL-0.75)
+}
+
+// feeExchange is a mockExchange that prices the BNB fee asset
+type feeExchange struct {
+	*mockExchange
+}
+
+func (e *feeExchange) GetSymbolPrice(ctx context.Context, symbol string) (float64, error) {
+	if symbol == "BNBUSDT" {
+		return 600.0, nil
+	}
+	return e.mockExchange.GetSymbolPrice(ctx, symbol)
+}
+
+func TestGridBotRecordsFees(t *testing.T) {
+	exchange := &feeExchange{mockExchange: &mockExchange{
+		currentPrice: 30000.0,
+		orders:       make(map[string]mockOrder),
+	}}
+	config := GridBotConfig{
+		Symbol:     "BTCUSDT",
+		LowerPrice: 25000.0,
+		UpperPrice: 35000.0,
+		GridNum:    5,
+		Investment: 1000.0,
+		Fees:       grid.Fees{MakerRate: 0.001, TakerRate: 0.001},
+	}
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	buy := gridOrder{order: types.Order{Side: "BUY", Price: 27500, Quantity: 0.01}, level: 1}
+
+	// Fees paid in BNB are converted at the BNB price
+	bot.recordFill(ctx, buy, []feeCharge{{asset: "BNB", amount: 0.0005}})
+	// Fees paid in base reduce the inventory received
+	bot.recordFill(ctx, buy, []feeCharge{{asset: "BTC", amount: 0.00001}})
+	// Unreported fees are estimated at the maker rate, taken from the base
+	// asset received
+	bot.recordFill(ctx, buy, nil)
+
+	pnl := bot.PnL()
+	assertClose(t, "Fees", pnl.Fees, 0.3+0.275+0.275)
+	assertClose(t, "EstimatedFees", pnl.EstimatedFees, 0.275)
+	assertClose(t, "BNB fees", pnl.FeesByAsset["BNB"], 0.0005)
+	assertClose(t, "Inventory", pnl.Inventory, 0.03-0.00001-0.00001)
+}
+
+func TestGridBotCounterSellAfterBaseFee(t *testing.T) {
+	info := types.SymbolInfo{
+		Symbol:     "BTCUSDT",
+		BaseAsset:  "BTC",
+		QuoteAsset: "USDT",
+		TickSize:   0.01,
+		StepSize:   0.00001,
+	}
+	exchange := &infoExchange{
+		mockExchange: &mockExchange{
+			currentPrice: 30000.0,
+			orders:       make(map[string]mockOrder),
+		},
+		info: info,
+	}
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	// The buy's commission is deducted from the BTC it receives
+	filled := exchange.fill(t, "BUY", 27500.0)
+	fee := filled.quantity * 0.001
+	bot.handleFill(ctx, filled.orderID, []feeCharge{{asset: "BTC", amount: fee}})
+
+	sell, ok := exchange.openOrder("SELL", 30000.0)
+	if !ok {
+		t.Fatal("Expected a counter-sell at 30000")
+	}
+	if want := grid.FloorToStep(filled.quantity-fee, info.StepSize); sell.quantity != want {
+		t.Errorf("Counter-sell quantity = %v, want %v: the %v bought less the %v commission",
+			sell.quantity, want, filled.quantity, fee)
+	}
+}
+
+func TestGridBotCounterSellAfterPolledBuy(t *testing.T) {
+	info := types.SymbolInfo{
+		Symbol:     "BTCUSDT",
+		BaseAsset:  "BTC",
+		QuoteAsset: "USDT",
+		TickSize:   0.01,
+		StepSize:   0.00001,
+	}
+	exchange := &infoExchange{
+		mockExchange: &mockExchange{
+			currentPrice: 30000.0,
+			orders:       make(map[string]mockOrder),
+		},
+		info: info,
+	}
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+		Fees:         grid.Fees{MakerRate: 0.001, TakerRate: 0.001},
+	}
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+	inventory := bot.PnL().Inventory
+
+	// Polling reports no commission, so the maker fee is assumed to come out
+	// of the BTC received
+	filled := exchange.fill(t, "BUY", 27500.0)
+	bot.CheckOrders(ctx)
+
+	fee := filled.quantity * 0.001
+	sell, ok := exchange.openOrder("SELL", 30000.0)
+	if !ok {
+		t.Fatal("Expected a counter-sell at 30000")
+	}
+	if want := grid.FloorToStep(filled.quantity-fee, info.StepSize); sell.quantity != want {
+		t.Errorf("Counter-sell quantity = %v, want %v: the %v bought less the estimated %v commission",
+			sell.quantity, want, filled.quantity, fee)
+	}
+	assertClose(t, "Inventory", bot.PnL().Inventory, inventory+filled.quantity-fee)
+}
+
+func TestGridBotPnLWithPaperExchange(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+		Fees:         grid.Fees{MakerRate: 0.001, TakerRate: 0.001},
+	}
+
+	paper, err := exchange.NewPaperExchange(exchange.SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 1000, "BTC": 1},
+		MakerFee: 0.001,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create paper exchange: %v", err)
+	}
+	paper.SetPrice(types.PriceUpdate{Price: 30000.0})
+
+	bot, err := NewGridBot(paper, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	// Opening inventory backs the sells at 32500 and 35000
+	if got, want := bot.PnL().Inventory, 200.0/32500+200.0/35000; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Opening inventory = %v, want %v", got, want)
+	}
+
+	paper.SetPrice(types.PriceUpdate{Price: 27000.0})
+	paper.SetPrice(types.PriceUpdate{Price: 30500.0})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for bot.PnL().RoundTrips < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a round trip")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pnl := bot.PnL()
+	quantity := 200.0 / 27500 * (1 - 0.001) // Less the commission taken from the buy
+	assertClose(t, "RealizedPnL", pnl.RealizedPnL, 2500*quantity)
+	assertClose(t, "Fees", pnl.Fees, paper.Fees())
+	if pnl.EstimatedFees != 0 {
+		t.Errorf("Expected reported fees only, got %v estimated", pnl.EstimatedFees)
+	}
+}
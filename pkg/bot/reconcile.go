@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+)
+
+// clientOrderIDPrefix marks orders placed by the grid bot
+const clientOrderIDPrefix = "grid_"
+
+// levelPriceTolerance is the relative distance within which an order price is
+// considered to sit on a grid level
+const levelPriceTolerance = 1e-6
+
+// newClientOrderID builds a unique client order ID that records the grid
+// level and side of an order, e.g. "grid_3_B_lq0x1k2r4s"
+func newClientOrderID(level int, side string) string {
+	return fmt.Sprintf("%s%d_%s_%s", clientOrderIDPrefix, level, side[:1],
+		strconv.FormatInt(time.Now().UnixNano(), 36))
+}
+
+// parseClientOrderID extracts the grid level and side from a client order ID
+// built by newClientOrderID
+func parseClientOrderID(clientOrderID string) (int, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(clientOrderID, clientOrderIDPrefix), "_")
+	if !strings.HasPrefix(clientOrderID, clientOrderIDPrefix) || len(parts) != 3 {
+		return 0, "", false
+	}
+
+	level, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", false
+	}
+
+	switch parts[1] {
+	case "B":
+		return level, "BUY", true
+	case "S":
+		return level, "SELL", true
+	}
+	return 0, "", false
+}
+
+// reconcile brings the tracked orders in line with the orders open on the
+// exchange: open orders sitting on a free grid level are adopted, all other
+// open orders for the symbol are canceled, tracked orders that are no longer
+// open are checked for fills, and orders are placed only at empty levels.
+func (b *GridBot) reconcile(ctx context.Context, currentPrice float64) error {
+	open, err := b.exchange.GetOpenOrders(ctx, b.config.Symbol)
+	if err != nil {
+		return err
+	}
+
+	openIDs := make(map[string]bool, len(open))
+	for _, info := range open {
+		openIDs[info.OrderID] = true
+	}
+
+	// Tracked orders that are no longer open were filled or canceled while
+	// the bot was down
+	b.mu.RLock()
+	var closed []string
+	for orderID := range b.orders {
+		if !openIDs[orderID] {
+			closed = append(closed, orderID)
+		}
+	}
+	b.mu.RUnlock()
+
+	// Orders placed by the bot take precedence over foreign orders at a level
+	sort.SliceStable(open, func(i, j int) bool {
+		_, _, iOwn := parseClientOrderID(open[i].ClientOrderID)
+		_, _, jOwn := parseClientOrderID(open[j].ClientOrderID)
+		return iOwn && !jOwn
+	})
+
+	var adopted, canceled int
+	for _, info := range open {
+		b.mu.RLock()
+		_, tracked := b.orders[info.OrderID]
+		b.mu.RUnlock()
+		if tracked {
+			continue
+		}
+
+		level := b.matchOrder(info, currentPrice)
+		if level < 0 || b.levelOccupied(level) {
+			if err := b.exchange.CancelOrder(ctx, b.config.Symbol, info.OrderID); err != nil {
+				b.logger.Error("Failed to cancel stray order", "orderID", info.OrderID, "side", info.Side,
+					"price", info.Price, "qty", info.Quantity, "error", err)
+				continue
+			}
+			b.logger.Info("Canceled stray order", "orderID", info.OrderID, "side", info.Side,
+				"price", info.Price, "qty", info.Quantity)
+			canceled++
+			continue
+		}
+
+		// Track the whole order so that its fill books the part executed
+		// before it was adopted as well
+		order := b.newOrder(info.Side, b.levels[level], info.Quantity)
+		order.ClientOrderID = info.ClientOrderID
+		b.mu.Lock()
+		b.orders[info.OrderID] = gridOrder{order: order, level: level}
+		if info.ExecutedQuantity > 0 {
+			b.filled[info.OrderID] = info.ExecutedQuantity
+		}
+		b.mu.Unlock()
+		adopted++
+	}
+
+	for _, orderID := range closed {
+		b.checkOrder(ctx, orderID)
+	}
+
+	if adopted > 0 || canceled > 0 {
+		b.logger.Info("Reconciled open orders", "adopted", adopted, "canceled", canceled)
+	}
+
+	err = b.fillGaps(ctx, currentPrice)
+	b.saveState()
+
+	return err
+}
+
+// plannedOrder is an order fillGaps intends to place at a grid level
+type plannedOrder struct {
+	level    int
+	side     string
+	quantity float64
+}
+
+// fillGaps places a buy order at every empty level below currentPrice and a
+// sell order at every empty level above it, after checking that the account
+// can fund all of them
+func (b *GridBot) fillGaps(ctx context.Context, currentPrice float64) error {
+	// Leave the level closest to the current price empty so that every
+	// filled order has a free adjacent level for its counter-order
+	skip := nearestLevel(b.levels, currentPrice)
+
+	var planned []plannedOrder
+	for i, level := range b.levels {
+		if i == skip || b.levelOccupied(i) {
+			continue
+		}
+
+		planned = append(planned, plannedOrder{level: i, side: levelSide(level, currentPrice), quantity: b.levelQuantity(i)})
+	}
+
+	if err := b.ensureBalances(ctx, planned, currentPrice); err != nil {
+		return err
+	}
+
+	for _, order := range planned {
+		if err := b.placeOrder(ctx, order.level, order.side, order.quantity); err != nil {
+			b.logger.Error("Failed to place order", "gridLevel", order.level, "side", order.side,
+				"price", b.levels[order.level], "qty", order.quantity, "error", err)
+		}
+	}
+	return nil
+}
+
+// matchOrder returns the grid level an open order belongs to, or -1. The
+// level recorded in the client order ID is preferred when its price matches;
+// otherwise the order must be on the side its level has at currentPrice.
+func (b *GridBot) matchOrder(info types.OrderInfo, currentPrice float64) int {
+	if level, side, ok := parseClientOrderID(info.ClientOrderID); ok && side == info.Side &&
+		level >= 0 && level < len(b.levels) && onLevel(info.Price, b.levels[level]) {
+		return level
+	}
+	for i, level := range b.levels {
+		if onLevel(info.Price, level) && info.Side == levelSide(level, currentPrice) {
+			return i
+		}
+	}
+	return -1
+}
+
+// levelSide returns the side of the order a level holds at currentPrice: a
+// buy below the price and a sell above it
+func levelSide(level, currentPrice float64) string {
+	if level < currentPrice {
+		return "BUY"
+	}
+	return "SELL"
+}
+
+// levelOccupied reports whether a tracked order sits on the given level
+func (b *GridBot) levelOccupied(level int) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, order := range b.orders {
+		if order.level == level {
+			return true
+		}
+	}
+	return false
+}
+
+// onLevel reports whether price sits on a grid level
+func onLevel(price, level float64) bool {
+	return math.Abs(price-level) <= level*levelPriceTolerance
+}
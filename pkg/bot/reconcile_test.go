@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClientOrderID(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		wantLevel int
+		wantSide  string
+		wantOK    bool
+	}{
+		{name: "Buy order", id: newClientOrderID(3, "BUY"), wantLevel: 3, wantSide: "BUY", wantOK: true},
+		{name: "Sell order", id: newClientOrderID(12, "SELL"), wantLevel: 12, wantSide: "SELL", wantOK: true},
+		{name: "Foreign order", id: "web_abc123", wantOK: false},
+		{name: "Malformed level", id: "grid_x_B_abc", wantOK: false},
+		{name: "Unknown side", id: "grid_1_X_abc", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.id) > 36 {
+				t.Errorf("Client order ID %q exceeds 36 characters", tt.id)
+			}
+			level, side, ok := parseClientOrderID(tt.id)
+			if ok != tt.wantOK || level != tt.wantLevel || side != tt.wantSide {
+				t.Errorf("parseClientOrderID(%q) = %d, %q, %v; want %d, %q, %v",
+					tt.id, level, side, ok, tt.wantLevel, tt.wantSide, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGridBotReconcilesOpenOrders(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 30000.0,
+		orders: map[string]mockOrder{
+			// Left over from a previous run
+			"adopt": {symbol: "BTCUSDT", side: "BUY", price: 25000.0, quantity: 0.004, orderID: "adopt",
+				clientOrderID: newClientOrderID(0, "BUY")},
+			"duplicate": {symbol: "BTCUSDT", side: "BUY", price: 25000.0, quantity: 0.004, orderID: "duplicate"},
+			"stray":     {symbol: "BTCUSDT", side: "BUY", price: 26000.0, quantity: 0.004, orderID: "stray"},
+			// Orders without a grid client order ID are matched by price and side
+			"foreign":   {symbol: "BTCUSDT", side: "SELL", price: 35000.0, quantity: 0.003, orderID: "foreign"},
+			"wrongSide": {symbol: "BTCUSDT", side: "SELL", price: 27500.0, quantity: 0.003, orderID: "wrongSide"},
+			// Other symbols are left alone
+			"other": {symbol: "ETHUSDT", side: "BUY", price: 1500.0, quantity: 0.1, orderID: "other"},
+		},
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	for _, orderID := range []string{"adopt", "foreign"} {
+		if _, ok := exchange.orders[orderID]; !ok {
+			t.Errorf("Expected matching order %s to be adopted", orderID)
+		}
+	}
+	if _, ok := exchange.orders["other"]; !ok {
+		t.Error("Expected order for another symbol to be left alone")
+	}
+	for _, orderID := range []string{"duplicate", "stray", "wrongSide"} {
+		if _, ok := exchange.orders[orderID]; ok {
+			t.Errorf("Expected order %s to be canceled", orderID)
+		}
+	}
+
+	// Only the gaps were filled
+	var buys25000 int
+	for _, order := range exchange.orders {
+		if order.side == "BUY" && order.price == 25000.0 {
+			buys25000++
+		}
+	}
+	if buys25000 != 1 {
+		t.Errorf("Expected exactly one buy at 25000, got %d", buys25000)
+	}
+	if _, ok := exchange.openOrder("BUY", 27500.0); !ok {
+		t.Error("Expected a buy at 27500 in place of the sell on the wrong side")
+	}
+	if got := bot.GetStatus().OpenOrders; got != 4 {
+		t.Errorf("Expected 4 tracked orders, got %v", got)
+	}
+}
+
+func TestGridBotAdoptsPartialFill(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 30000.0,
+		orders: map[string]mockOrder{
+			// Left over from a previous run and partly filled meanwhile
+			"partial": {symbol: "BTCUSDT", side: "BUY", price: 27500.0, quantity: 0.008, orderID: "partial",
+				clientOrderID: newClientOrderID(1, "BUY"), executed: 0.005},
+		},
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	level := bot.GetStatus().Levels[1]
+	if level.OrderID != "partial" || level.Quantity != 0.008 || level.FilledQuantity != 0.005 {
+		t.Errorf("Adopted level = %+v, want the whole order with 0.005 filled", level)
+	}
+
+	// The fill books the whole order, including the part executed before
+	// it was adopted
+	inventory := bot.PnL().Inventory
+	exchange.fill(t, "BUY", 27500.0)
+	bot.CheckOrders(ctx)
+
+	if sell, ok := exchange.openOrder("SELL", 30000.0); !ok || sell.quantity != 0.008 {
+		t.Errorf("Expected a counter-sell of 0.008 at 30000, got %+v", sell)
+	}
+	if status := bot.GetStatus(); status.Fills != 1 {
+		t.Errorf("Expected 1 recorded fill, got %d", status.Fills)
+	}
+	assertClose(t, "Inventory", bot.PnL().Inventory, inventory+0.008)
+}
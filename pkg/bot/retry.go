@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+)
+
+const (
+	// minOrderRetryDelay is the delay before a grid order that failed to
+	// place is first placed again
+	minOrderRetryDelay = 5 * time.Second
+	// maxOrderRetryDelay caps the delay between attempts to place a grid order
+	maxOrderRetryDelay = 5 * time.Minute
+)
+
+// failedOrder is a grid order whose placement failed with a retryable error.
+// It is kept in memory only; after a restart the reconciliation fills the
+// empty level instead.
+type failedOrder struct {
+	side          string
+	quantity      float64
+	clientOrderID string    // Placed again under the same ID, so an attempt that reached the exchange is not duplicated
+	attempts      int       // Failed placements so far
+	retryAt       time.Time // When the order is placed again
+}
+
+// scheduleRetry records that the order at level failed to place, to be placed
+// again after a jittered delay that doubles with every failed attempt
+func (b *GridBot) scheduleRetry(level int, side string, quantity float64, clientOrderID string) {
+	b.mu.Lock()
+	failed := b.failed[level]
+	if failed.side != side {
+		failed = failedOrder{side: side, clientOrderID: clientOrderID}
+	}
+	failed.quantity = quantity
+	failed.attempts++
+	delay := min(minOrderRetryDelay<<min(failed.attempts-1, 16), maxOrderRetryDelay)
+	delay = delay/2 + rand.N(delay/2)
+	failed.retryAt = time.Now().Add(delay)
+	b.failed[level] = failed
+	b.mu.Unlock()
+
+	b.logger.Warn("Order placement failed, retrying later", "gridLevel", level, "side", side,
+		"qty", quantity, "attempts", failed.attempts, "retryIn", delay)
+}
+
+// retryFailedOrders places the orders that failed to place once their delay
+// has passed. The run loop calls it every PollInterval. Levels that got an
+// order meanwhile are dropped, and so are orders failing with an error that is
+// not retryable.
+func (b *GridBot) retryFailedOrders(ctx context.Context) {
+	now := time.Now()
+	b.mu.RLock()
+	var due []int
+	for level, failed := range b.failed {
+		if !now.Before(failed.retryAt) {
+			due = append(due, level)
+		}
+	}
+	b.mu.RUnlock()
+	sort.Ints(due)
+
+	for _, level := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		b.mu.RLock()
+		failed, ok := b.failed[level]
+		b.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if b.levelOccupied(level) {
+			b.mu.Lock()
+			delete(b.failed, level)
+			b.mu.Unlock()
+			continue
+		}
+
+		err := b.submitOrder(ctx, level, failed.side, failed.quantity, failed.clientOrderID)
+		if err != nil && !types.IsRetryable(err) {
+			b.logger.Error("Giving up on order", "gridLevel", level, "side", failed.side,
+				"price", b.levels[level], "qty", failed.quantity, "attempts", failed.attempts+1, "error", err)
+		}
+	}
+}
+
+// shiftFailedOrders moves the failed orders to the levels mapped by moved,
+// dropping those whose level has no mapping. b.mu must be held.
+func (b *GridBot) shiftFailedOrders(moved func(level int) (int, bool)) {
+	shifted := make(map[int]failedOrder, len(b.failed))
+	for level, failed := range b.failed {
+		if to, ok := moved(level); ok {
+			shifted[to] = failed
+		}
+	}
+	b.failed = shifted
+}
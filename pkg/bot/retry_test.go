@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/types"
+)
+
+func TestGridBotRetriesFailedOrders(t *testing.T) {
+	tests := []struct {
+		name        string
+		placeErr    error
+		wantRetries int // Levels waiting for a retry after the grid failed to place
+	}{
+		{
+			name:        "Retryable error",
+			placeErr:    fmt.Errorf("failed to place order: %w: timeout", types.ErrUnavailable),
+			wantRetries: 4,
+		},
+		{
+			name:        "Fatal error",
+			placeErr:    fmt.Errorf("failed to place order: %w", types.ErrOrderRejected),
+			wantRetries: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GridBotConfig{
+				Symbol:       "BTCUSDT",
+				LowerPrice:   25000.0,
+				UpperPrice:   35000.0,
+				GridNum:      5,
+				Investment:   1000.0,
+				PollInterval: time.Hour,
+			}
+
+			exchange := &failingExchange{
+				mockExchange: &mockExchange{
+					currentPrice: 31000.0,
+					orders:       make(map[string]mockOrder),
+				},
+				placeErr: tt.placeErr,
+			}
+
+			bot, err := NewGridBot(exchange, config)
+			if err != nil {
+				t.Fatalf("Failed to create bot: %v", err)
+			}
+			ctx := context.Background()
+			if err := bot.Start(ctx); err != nil {
+				t.Fatalf("Failed to start bot: %v", err)
+			}
+			defer bot.Stop(ctx)
+
+			retries := func() int {
+				var n int
+				for _, level := range bot.GetStatus().Levels {
+					if level.RetryAt != nil {
+						n++
+					}
+				}
+				return n
+			}
+			// Make every failed order due for a retry
+			due := func() {
+				bot.mu.Lock()
+				for level, failed := range bot.failed {
+					failed.retryAt = time.Time{}
+					bot.failed[level] = failed
+				}
+				bot.mu.Unlock()
+			}
+
+			if got := retries(); got != tt.wantRetries {
+				t.Fatalf("Levels waiting for a retry = %d, want %d", got, tt.wantRetries)
+			}
+
+			// Orders still failing are retried later again
+			due()
+			bot.retryFailedOrders(ctx)
+			if got := retries(); got != tt.wantRetries {
+				t.Errorf("Levels waiting for a retry after failing again = %d, want %d", got, tt.wantRetries)
+			}
+			bot.mu.RLock()
+			for level, failed := range bot.failed {
+				if failed.attempts != 2 {
+					t.Errorf("Level %d failed %d times, want 2", level, failed.attempts)
+				}
+			}
+			bot.mu.RUnlock()
+
+			exchange.placeErr = nil
+			due()
+			bot.retryFailedOrders(ctx)
+			if status := bot.GetStatus(); status.OpenOrders != tt.wantRetries || retries() != 0 {
+				t.Errorf("Expected %d orders placed by the retries and none left to retry, got %d orders and %d retries",
+					tt.wantRetries, status.OpenOrders, retries())
+			}
+		})
+	}
+}
+
+// lostReplyExchange places orders but reports the first ones as failed, like a
+// request that timed out after reaching the exchange
+type lostReplyExchange struct {
+	*exchange.SimulatedExchange
+	lostReplies int
+}
+
+func (e *lostReplyExchange) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	orderID, err := e.SimulatedExchange.PlaceOrder(ctx, order)
+	if err == nil && e.lostReplies > 0 {
+		e.lostReplies--
+		return "", fmt.Errorf("failed to place order: %w: timeout", types.ErrUnavailable)
+	}
+	return orderID, err
+}
+
+func TestGridBotRetryKeepsClientOrderID(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	sim, err := exchange.NewSimulatedExchange(exchange.SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 1000, "BTC": 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create simulated exchange: %v", err)
+	}
+	sim.SetPrice(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 31000.0)
+	lossy := &lostReplyExchange{SimulatedExchange: sim, lostReplies: 1}
+
+	bot, err := NewGridBot(lossy, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	// The first order reached the exchange although the bot saw it fail
+	bot.mu.Lock()
+	if len(bot.failed) != 1 {
+		t.Fatalf("Levels waiting for a retry = %d, want 1", len(bot.failed))
+	}
+	for level, failed := range bot.failed {
+		failed.retryAt = time.Time{}
+		bot.failed[level] = failed
+	}
+	bot.mu.Unlock()
+
+	bot.retryFailedOrders(ctx)
+	open, err := sim.GetOpenOrders(ctx, config.Symbol)
+	if err != nil {
+		t.Fatalf("GetOpenOrders() error = %v", err)
+	}
+	status := bot.GetStatus()
+	if len(open) != 4 || status.OpenOrders != 4 {
+		t.Errorf("Expected the retry to track the order placed before instead of placing another, got %d open orders and %d tracked",
+			len(open), status.OpenOrders)
+	}
+	for _, info := range open {
+		if _, ok := bot.orders[info.OrderID]; !ok {
+			t.Errorf("Open %s order at %.2f is not tracked", info.Side, info.Price)
+		}
+	}
+	if len(bot.failed) != 0 {
+		t.Errorf("Levels waiting for a retry after it = %d, want 0", len(bot.failed))
+	}
+}
@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StateStore persists the bot's state so it can resume after a restart
+type StateStore interface {
+	// Load returns the saved state, or nil if nothing has been saved yet
+	Load() (*State, error)
+	// Save replaces the saved state
+	Save(state State) error
+}
+
+// State is a snapshot of everything the bot needs to resume trading
+type State struct {
+	Config    GridBotConfig `json:"config"`
+	Levels    []float64     `json:"levels"`
+	Orders    []OrderRecord `json:"orders"`
+	Fills     []Fill        `json:"fills"`
+	Ledger    Ledger        `json:"ledger"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// OrderRecord maps an open order to its grid level
+type OrderRecord struct {
+	OrderID       string  `json:"orderId"`
+	ClientOrderID string  `json:"clientOrderId,omitempty"`
+	Level         int     `json:"level"`
+	Side          string  `json:"side"`
+	Price         float64 `json:"price"`
+	Quantity      float64 `json:"quantity"`
+}
+
+// Fill records a filled grid order
+type Fill struct {
+	OrderID  string    `json:"orderId"`
+	Level    int       `json:"level"`
+	Side     string    `json:"side"`
+	Price    float64   `json:"price"`
+	Quantity float64   `json:"quantity"`
+	Time     time.Time `json:"time"`
+}
+
+// WithStateStore makes the bot persist its state to store and resume from it
+// on Start
+func WithStateStore(store StateStore) Option {
+	return func(b *GridBot) {
+		b.store = store
+	}
+}
+
+// restoreState loads the saved state, if any. Restored orders are verified
+// against the exchange when the bot reconciles on start.
+func (b *GridBot) restoreState() error {
+	if b.store == nil {
+		return nil
+	}
+
+	state, err := b.store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if state == nil {
+		return nil
+	}
+	if !sameGrid(state.Config, b.config) {
+		return fmt.Errorf("saved state is for a different grid (%s %.2f-%.2f, %d %s grids, investment %.2f); "+
+			"restore that configuration or remove the saved state",
+			state.Config.Symbol, state.Config.LowerPrice, state.Config.UpperPrice,
+			state.Config.GridNum, state.Config.Spacing, state.Config.Investment)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.fills = state.Fills
+	b.ledger = *state.Ledger.clone()
+	if len(state.Levels) > 0 {
+		// The levels may have moved with a trailing grid
+		b.levels = state.Levels
+		if err := b.sizeLevels(); err != nil {
+			return fmt.Errorf("invalid saved grid levels: %w", err)
+		}
+	}
+	if len(state.Orders) == 0 {
+		return nil
+	}
+
+	for _, record := range state.Orders {
+		order := b.newOrder(record.Side, record.Price, record.Quantity)
+		order.ClientOrderID = record.ClientOrderID
+		b.orders[record.OrderID] = gridOrder{order: order, level: record.Level}
+	}
+
+	b.logger.Info("Resuming open orders from saved state", "orders", len(state.Orders))
+	return nil
+}
+
+// saveState persists the current state if a store is configured
+func (b *GridBot) saveState() {
+	if b.store == nil {
+		return
+	}
+
+	b.mu.RLock()
+	state := State{
+		Config:    b.config,
+		Levels:    append([]float64(nil), b.levels...),
+		Orders:    make([]OrderRecord, 0, len(b.orders)),
+		Fills:     append([]Fill(nil), b.fills...),
+		Ledger:    *b.ledger.clone(),
+		UpdatedAt: time.Now(),
+	}
+	for orderID, order := range b.orders {
+		state.Orders = append(state.Orders, OrderRecord{
+			OrderID:       orderID,
+			ClientOrderID: order.order.ClientOrderID,
+			Level:         order.level,
+			Side:          order.order.Side,
+			Price:         order.order.Price,
+			Quantity:      order.order.Quantity,
+		})
+	}
+	b.mu.RUnlock()
+
+	if err := b.store.Save(state); err != nil {
+		b.logger.Error("Failed to save state", "error", err)
+	}
+}
+
+// sameGrid reports whether two configurations describe the same grid
+func sameGrid(a, b GridBotConfig) bool {
+	return a.Symbol == b.Symbol &&
+		a.LowerPrice == b.LowerPrice &&
+		a.UpperPrice == b.UpperPrice &&
+		a.GridNum == b.GridNum &&
+		a.Spacing == b.Spacing &&
+		slices.Equal(a.Levels, b.Levels) &&
+		a.CenterPrice == b.CenterPrice &&
+		a.Concentration == b.Concentration &&
+		a.Sizing == b.Sizing &&
+		a.SizeMultiplier == b.SizeMultiplier &&
+		a.Investment == b.Investment
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory StateStore
+type memStore struct {
+	state *State
+	saves int
+}
+
+func (m *memStore) Load() (*State, error) {
+	return m.state, nil
+}
+
+func (m *memStore) Save(state State) error {
+	m.state = &state
+	m.saves++
+	return nil
+}
+
+func TestGridBotResumesFromState(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 30000.0,
+		orders:       make(map[string]mockOrder),
+	}
+	store := &memStore{}
+
+	first, err := NewGridBot(exchange, config, WithStateStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := first.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	if store.state == nil || len(store.state.Orders) != 4 {
+		t.Fatalf("Expected 4 orders in saved state, got %+v", store.state)
+	}
+
+	// Simulate a crash: the loop stops but orders stay on the exchange
+	cancel()
+	<-first.done
+
+	// A sell fills while the bot is down
+	exchange.fill(t, "SELL", 32500.0)
+	exchange.currentPrice = 32500.0
+
+	second, err := NewGridBot(exchange, config, WithStateStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	if err := second.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to resume bot: %v", err)
+	}
+	defer second.Stop(context.Background())
+
+	// Only the counter-order for the missed fill is placed
+	if len(exchange.orders) != 4 {
+		t.Errorf("Expected 4 open orders after resume, got %d", len(exchange.orders))
+	}
+	if _, ok := exchange.openOrder("BUY", 30000.0); !ok {
+		t.Error("Expected counter-order for the fill missed while down")
+	}
+
+	status := second.GetStatus()
+	if status.OpenOrders != 4 {
+		t.Errorf("Expected 4 tracked orders, got %v", status.OpenOrders)
+	}
+	if status.Fills != 1 {
+		t.Errorf("Expected 1 recorded fill, got %v", status.Fills)
+	}
+	if got, want := store.state.Ledger.RealizedPnL, (32500.0-30000.0)*(1000.0/5/32500.0); got != want {
+		t.Errorf("Expected realized PnL %.8f, got %.8f", want, got)
+	}
+}
+
+func TestGridBotRejectsStateForDifferentGrid(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:     "BTCUSDT",
+		LowerPrice: 25000.0,
+		UpperPrice: 35000.0,
+		GridNum:    5,
+		Investment: 1000.0,
+	}
+
+	saved := config
+	saved.GridNum = 7
+	store := &memStore{state: &State{Config: saved}}
+
+	exchange := &mockExchange{
+		currentPrice: 30000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config, WithStateStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	if err := bot.Start(context.Background()); err == nil {
+		t.Error("Expected Start to fail for saved state of a different grid")
+	}
+	if len(exchange.orders) != 0 {
+		t.Errorf("Expected no orders to be placed, got %d", len(exchange.orders))
+	}
+}
+
+func TestGridBotRestoresLevelsWithoutOrders(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour,
+	}
+
+	// Saved by a bot that trailed up one level and then stopped, canceling
+	// its orders
+	levels := []float64{27500, 30000, 32500, 35000, 37500}
+	store := &memStore{}
+
+	exchange := &mockExchange{
+		currentPrice: 32000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config, WithStateStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	store.state = &State{Config: bot.config, Levels: levels}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	status := bot.GetStatus()
+	if len(status.Levels) != len(levels) {
+		t.Fatalf("Expected %d levels, got %d", len(levels), len(status.Levels))
+	}
+	for i, level := range status.Levels {
+		if level.Price != levels[i] {
+			t.Errorf("Level %d = %v, want the saved %v", i, level.Price, levels[i])
+		}
+	}
+	if _, ok := exchange.openOrder("SELL", 37500.0); !ok {
+		t.Error("Expected a sell at the saved top level 37500")
+	}
+}
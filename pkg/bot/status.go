@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"time"
+)
+
+// Status is a point-in-time snapshot of the bot. It shares no memory with
+// the bot and can be serialized as JSON.
+type Status struct {
+	State      RunState      `json:"state"`
+	Error      string        `json:"error,omitempty"` // Why the bot is in the error state
+	Running    bool          `json:"running"`         // Started and not yet stopped, including while paused
+	Paused     bool          `json:"paused"`          // Handling no fills and placing no orders
+	Config     GridBotConfig `json:"config"`          // Bounds and grid count are those of the generated levels, before any trailing
+	StartPrice float64       `json:"startPrice"`
+	LastPrice  float64       `json:"lastPrice"`
+	OutOfRange bool          `json:"outOfRange"`           // Whether LastPrice is outside the grid
+	StopReason StopReason    `json:"stopReason,omitempty"` // Why the bot last stopped, empty while running
+
+	Levels     []LevelStatus      `json:"levels"` // Current levels, which move with a trailing grid
+	OpenOrders int                `json:"openOrders"`
+	Fills      int                `json:"fills"`
+	Balances   map[string]float64 `json:"balances,omitempty"` // Free balances as last queried
+	PnL        PnL                `json:"pnl"`
+
+	// Times of events that have not happened yet are nil
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	LastPriceAt *time.Time `json:"lastPriceAt,omitempty"`
+	LastFillAt  *time.Time `json:"lastFillAt,omitempty"`
+	BalancesAt  *time.Time `json:"balancesAt,omitempty"`
+	StoppedAt   *time.Time `json:"stoppedAt,omitempty"`
+	Time        time.Time  `json:"time"` // When the snapshot was taken
+}
+
+// LevelStatus describes a grid level and the order resting on it, if any
+type LevelStatus struct {
+	Price          float64    `json:"price"`
+	Side           string     `json:"side,omitempty"` // BUY or SELL, empty when the level has no order
+	OrderID        string     `json:"orderId,omitempty"`
+	Quantity       float64    `json:"quantity,omitempty"`       // Order quantity
+	FilledQuantity float64    `json:"filledQuantity,omitempty"` // Quantity filled so far
+	RetryAt        *time.Time `json:"retryAt,omitempty"`        // When an order that failed to place is placed again, nil if none
+}
+
+// GetStatus returns a snapshot of the bot's state
+func (b *GridBot) GetStatus() Status {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	config := b.config
+	config.Levels = append([]float64(nil), b.config.Levels...)
+
+	status := Status{
+		State:       b.state,
+		Running:     b.state.started(),
+		Paused:      b.state == RunStatePaused,
+		Config:      config,
+		StartPrice:  b.startPrice,
+		LastPrice:   b.lastPrice,
+		OutOfRange:  b.outOfRange,
+		Levels:      make([]LevelStatus, len(b.levels)),
+		OpenOrders:  len(b.orders),
+		Fills:       len(b.fills),
+		PnL:         b.ledger.pnl(b.lastPrice),
+		StartedAt:   timeOrNil(b.startedAt),
+		LastPriceAt: timeOrNil(b.lastPriceAt),
+		LastFillAt:  timeOrNil(b.lastFillAt),
+		BalancesAt:  timeOrNil(b.balancesAt),
+		StoppedAt:   timeOrNil(b.stoppedAt),
+		StopReason:  b.stopReason,
+		Time:        time.Now(),
+	}
+	if b.stateErr != nil {
+		status.Error = b.stateErr.Error()
+	}
+	for i, price := range b.levels {
+		status.Levels[i].Price = price
+	}
+	for orderID, order := range b.orders {
+		level := &status.Levels[order.level]
+		level.Side = order.order.Side
+		level.OrderID = orderID
+		level.Quantity = order.order.Quantity
+		level.FilledQuantity = b.filled[orderID]
+	}
+	for i, failed := range b.failed {
+		status.Levels[i].RetryAt = timeOrNil(failed.retryAt)
+	}
+	if len(b.lastBalances) > 0 {
+		status.Balances = make(map[string]float64, len(b.lastBalances))
+		for asset, balance := range b.lastBalances {
+			status.Balances[asset] = balance
+		}
+	}
+	return status
+}
+
+// timeOrNil returns a copy of t, or nil if t is zero
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
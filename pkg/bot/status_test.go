@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+)
+
+func TestGridBotStatus(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000.0,
+		UpperPrice:   35000.0,
+		GridNum:      5,
+		Investment:   1000.0,
+		PollInterval: time.Hour, // Fills are checked manually below
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := bot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer bot.Stop(ctx)
+
+	buy, ok := exchange.openOrder("BUY", 27500.0)
+	if !ok {
+		t.Fatal("Expected a buy order at 27500")
+	}
+	bot.handleReport(ctx, types.ExecutionReport{
+		Symbol:             buy.symbol,
+		OrderID:            buy.orderID,
+		Side:               buy.side,
+		Status:             types.OrderStatusPartiallyFilled,
+		Price:              buy.price,
+		Quantity:           buy.quantity,
+		CumulativeQuantity: buy.quantity / 2,
+	})
+
+	status := bot.GetStatus()
+	if !status.Running || status.StartPrice != 31000.0 || status.StartedAt == nil {
+		t.Errorf("Unexpected run state: running %v, start price %.2f, started at %v",
+			status.Running, status.StartPrice, status.StartedAt)
+	}
+	if status.Config.Symbol != "BTCUSDT" || status.Config.GridNum != 5 {
+		t.Errorf("Unexpected config in status: %+v", status.Config)
+	}
+	if len(status.Levels) != 5 || status.OpenOrders != 4 {
+		t.Fatalf("Expected 5 levels and 4 open orders, got %d and %d", len(status.Levels), status.OpenOrders)
+	}
+
+	tests := []struct {
+		name     string
+		level    int
+		price    float64
+		side     string
+		filled   float64
+		hasOrder bool
+	}{
+		{name: "Untouched buy", level: 0, price: 25000.0, side: "BUY", hasOrder: true},
+		{name: "Partially filled buy", level: 1, price: 27500.0, side: "BUY", filled: buy.quantity / 2, hasOrder: true},
+		{name: "Level closest to the start price", level: 2, price: 30000.0},
+		{name: "Sell above the start price", level: 3, price: 32500.0, side: "SELL", hasOrder: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := status.Levels[tt.level]
+			if level.Price != tt.price || level.Side != tt.side || level.FilledQuantity != tt.filled {
+				t.Errorf("Level %d = %+v, want price %.2f, side %q, filled %.8f",
+					tt.level, level, tt.price, tt.side, tt.filled)
+			}
+			if (level.OrderID != "") != tt.hasOrder {
+				t.Errorf("Level %d order ID = %q, want order: %v", tt.level, level.OrderID, tt.hasOrder)
+			}
+		})
+	}
+
+	// The snapshot does not share memory with the bot
+	status.Levels[0].Price = 0
+	status.Config.Symbol = "ETHUSDT"
+	if again := bot.GetStatus(); again.Levels[0].Price != 25000.0 || again.Config.Symbol != "BTCUSDT" {
+		t.Error("Modifying a status snapshot changed the bot")
+	}
+
+	data, err := json.Marshal(bot.GetStatus())
+	if err != nil {
+		t.Fatalf("Failed to marshal status: %v", err)
+	}
+	var decoded Status
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal status: %v", err)
+	}
+	if len(decoded.Levels) != 5 || decoded.Levels[1].FilledQuantity != buy.quantity/2 || decoded.OpenOrders != 4 {
+		t.Errorf("Status did not survive a JSON round trip: %s", data)
+	}
+}
+
+func TestGridBotStatusJSONBeforeStart(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:     "BTCUSDT",
+		LowerPrice: 25000.0,
+		UpperPrice: 35000.0,
+		GridNum:    5,
+		Investment: 1000.0,
+	}
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	data, err := json.Marshal(bot.GetStatus())
+	if err != nil {
+		t.Fatalf("Failed to marshal status: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to unmarshal status: %v", err)
+	}
+	// Events that have not happened are left out rather than sent as year 1
+	for _, key := range []string{"startedAt", "lastPriceAt", "lastFillAt", "balancesAt", "stoppedAt"} {
+		if value, ok := fields[key]; ok {
+			t.Errorf("Expected no %s before the bot starts, got %v", key, value)
+		}
+	}
+	if _, ok := fields["time"]; !ok {
+		t.Errorf("Expected the snapshot time in %s", data)
+	}
+	if strings.Contains(string(data), "0001-01-01") {
+		t.Errorf("Status contains a zero time: %s", data)
+	}
+}
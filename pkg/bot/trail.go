@@ -0,0 +1,289 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"spot_grid_bot/pkg/grid"
+)
+
+// TrailMode determines in which directions the grid follows the price out of
+// its range
+type TrailMode string
+
+const (
+	// TrailOff keeps the grid fixed
+	TrailOff TrailMode = "off"
+	// TrailUp moves the grid up when the price breaks above it
+	TrailUp TrailMode = "up"
+	// TrailDown moves the grid down when the price breaks below it
+	TrailDown TrailMode = "down"
+	// TrailBoth moves the grid in either direction
+	TrailBoth TrailMode = "both"
+)
+
+// ParseTrailMode converts a mode name into a TrailMode
+func ParseTrailMode(name string) (TrailMode, error) {
+	switch mode := TrailMode(name); mode {
+	case TrailOff, TrailUp, TrailDown, TrailBoth:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown trailing mode %q, expected %q, %q, %q or %q",
+		name, TrailOff, TrailUp, TrailDown, TrailBoth)
+}
+
+// up reports whether the grid trails breakouts above its range
+func (m TrailMode) up() bool {
+	return m == TrailUp || m == TrailBoth
+}
+
+// down reports whether the grid trails breakouts below its range
+func (m TrailMode) down() bool {
+	return m == TrailDown || m == TrailBoth
+}
+
+// validateTrailing checks the trailing configuration
+func validateTrailing(config GridBotConfig) error {
+	if config.Trailing != "" {
+		if _, err := ParseTrailMode(string(config.Trailing)); err != nil {
+			return err
+		}
+	}
+	if config.TrailLowerLimit < 0 || config.TrailUpperLimit < 0 {
+		return fmt.Errorf("trailing limits must not be negative")
+	}
+	if config.TrailLowerLimit > 0 && config.TrailUpperLimit > 0 && config.TrailLowerLimit >= config.TrailUpperLimit {
+		return fmt.Errorf("trailing lower limit %.2f must be below the upper limit %.2f",
+			config.TrailLowerLimit, config.TrailUpperLimit)
+	}
+	return nil
+}
+
+// UpdatePrice records a new market price and, in trailing mode, moves the grid
+// after it when the price has left the range. The running bot does this for
+// every streamed price; simulations that move the market themselves call it
+// after CheckOrders on every price change. A stop-loss or take-profit the
+// price crosses has stopped the bot when UpdatePrice returns.
+func (b *GridBot) UpdatePrice(ctx context.Context, price float64) {
+	b.updatePrice(ctx, price, false)
+}
+
+// updatePrice implements UpdatePrice. The watch loop passes async to stop the
+// bot on another goroutine, since stopping waits for the loop to exit.
+func (b *GridBot) updatePrice(ctx context.Context, price float64, async bool) {
+	reason, action, fired := b.handlePrice(price)
+	switch {
+	case fired && async:
+		go b.triggerStop(reason, action, price)
+	case fired:
+		b.triggerStop(reason, action, price)
+	default:
+		b.trail(ctx, price)
+	}
+}
+
+// trail shifts the grid one level at a time toward price until price is back
+// inside the range or the grid cannot move further
+func (b *GridBot) trail(ctx context.Context, price float64) {
+	if b.config.Trailing == TrailOff || price <= 0 || !b.trading() {
+		return
+	}
+
+	// A grid of n levels is fully replaced after n steps
+	for steps := 0; steps < len(b.levels) && ctx.Err() == nil; steps++ {
+		b.mu.RLock()
+		lower, upper := b.levels[0], b.levels[len(b.levels)-1]
+		triggered := b.triggered
+		b.mu.RUnlock()
+
+		var up bool
+		switch {
+		case triggered:
+			return
+		case price > upper && b.config.Trailing.up():
+			up = true
+		case price < lower && b.config.Trailing.down():
+			up = false
+		default:
+			b.mu.Lock()
+			b.trailStall = ""
+			b.mu.Unlock()
+			return
+		}
+
+		if b.breakoutPending(up) {
+			// Trail once the fills are handled so their counter-orders land
+			// on the current levels
+			return
+		}
+		if reason := b.shiftGrid(ctx, up); reason != "" {
+			b.mu.Lock()
+			stalled := reason != b.trailStall
+			b.trailStall = reason
+			b.mu.Unlock()
+			if stalled {
+				b.logger.Warn("Grid cannot trail the price", "price", price, "reason", reason,
+					"lowerPrice", lower, "upperPrice", upper)
+			}
+			return
+		}
+	}
+}
+
+// breakoutPending reports whether orders the price has passed in a breakout
+// up or down are still tracked because their fills have not been seen yet
+func (b *GridBot) breakoutPending(up bool) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, order := range b.orders {
+		if (up && order.order.Side == "SELL") || (!up && order.order.Side == "BUY") {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftGrid moves the grid one level up or down: the order on the level
+// farthest from the breakout is canceled, a level is added beyond the edge in
+// the breakout direction and the canceled order's funds are placed again
+// next to the breakout. It returns why the grid could not move, or an empty
+// string.
+func (b *GridBot) shiftGrid(ctx context.Context, up bool) string {
+	b.mu.RLock()
+	levels := append([]float64(nil), b.levels...)
+	b.mu.RUnlock()
+
+	n := len(levels)
+	next := grid.RoundToTick(nextLevel(levels, b.config.Spacing, up), b.symbolInfo.TickSize)
+
+	// Levels after the shift, and the level removed and the one whose funds
+	// are placed again in the old layout
+	var shifted []float64
+	var removed, refill, offset int
+	if up {
+		if next <= levels[n-1] {
+			return "tick size too coarse for the next level"
+		}
+		if b.config.TrailUpperLimit > 0 && next > b.config.TrailUpperLimit {
+			return fmt.Sprintf("next level %.8g is above the trailing upper limit", next)
+		}
+		shifted = append(levels[1:n:n], next)
+		removed, refill, offset = 0, n-1, -1
+	} else {
+		if next <= 0 || next >= levels[0] {
+			return "no room for a lower level"
+		}
+		if b.config.TrailLowerLimit > 0 && next < b.config.TrailLowerLimit {
+			return fmt.Sprintf("next level %.8g is below the trailing lower limit", next)
+		}
+		shifted = append([]float64{next}, levels[:n-1]...)
+		removed, refill, offset = n-1, 0, 1
+	}
+
+	edge := []float64{shifted[0], shifted[1]}
+	if up {
+		edge = []float64{shifted[n-2], shifted[n-1]}
+	}
+	if err := grid.ValidateGridProfit(edge, b.config.Fees, b.config.MinGridProfit); err != nil &&
+		b.config.ProfitPolicy != grid.ProfitPolicyWarn {
+		return err.Error()
+	}
+	quantities, err := b.levelQuantities(shifted)
+	if err != nil {
+		return err.Error()
+	}
+
+	// Free the funds of the farthest order
+	var canceledID string
+	var canceled gridOrder
+	var remaining float64
+	b.mu.RLock()
+	for orderID, order := range b.orders {
+		if order.level == removed {
+			canceledID, canceled = orderID, order
+			remaining = order.order.Quantity - b.filled[orderID]
+			break
+		}
+	}
+	b.mu.RUnlock()
+	if canceledID != "" {
+		filled, err := b.cancelOrder(ctx, canceledID)
+		if err != nil {
+			b.logger.Error("Failed to cancel order to trail the grid", append(canceled.logAttrs(canceledID), "error", err)...)
+			return "failed to cancel the farthest order"
+		}
+		if filled {
+			// The order's funds went into the fill
+			remaining = 0
+		}
+	}
+
+	b.mu.Lock()
+	for orderID, order := range b.orders {
+		order.level += offset
+		b.orders[orderID] = order
+	}
+	// Keep lots paired with the level they were bought at
+	for i := range b.ledger.Lots {
+		b.ledger.Lots[i].Level += offset
+	}
+	b.shiftFailedOrders(func(level int) (int, bool) {
+		return level + offset, level != removed
+	})
+	b.levels = shifted
+	b.quantities = quantities
+	b.interval = levelsBelow(shifted, b.lastPrice)
+	b.outOfRange = b.lastPrice < shifted[0] || b.lastPrice > shifted[n-1]
+	b.trailStall = ""
+	b.mu.Unlock()
+	b.saveState()
+
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	b.logger.Info("Trailed grid", "direction", direction, "removedLevel", levels[removed], "addedLevel", next,
+		"lowerPrice", shifted[0], "upperPrice", shifted[n-1])
+
+	// Place the freed funds at the old edge: quote from a canceled buy buys
+	// below the price, base from a canceled sell sells above it
+	side := "SELL"
+	if up {
+		side = "BUY"
+	}
+	if canceledID == "" || canceled.order.Side != side || remaining <= 0 {
+		return ""
+	}
+	level := refill + offset
+	quantity := remaining
+	if up {
+		quantity = remaining * canceled.order.Price / shifted[level]
+	}
+	order := b.newOrder(side, shifted[level], quantity)
+	if order.Quantity <= 0 || order.Quantity < b.symbolInfo.MinQty || order.Quantity*order.Price < b.symbolInfo.MinNotional {
+		b.logger.Info("Freed funds too small for an order", "gridLevel", level, "side", side, "qty", order.Quantity)
+		return ""
+	}
+	if b.levelOccupied(level) {
+		return ""
+	}
+	if err := b.placeOrder(ctx, level, side, quantity); err != nil {
+		b.logger.Error("Failed to place order after trailing", "gridLevel", level, "side", side,
+			"price", shifted[level], "qty", quantity, "error", err)
+	}
+	return ""
+}
+
+// nextLevel returns the price one grid step beyond the upper or lower edge of
+// levels, repeating the spacing of the edge grid
+func nextLevel(levels []float64, spacing grid.Spacing, up bool) float64 {
+	edge, inner := levels[0], levels[1]
+	if up {
+		edge, inner = levels[len(levels)-1], levels[len(levels)-2]
+	}
+	if spacing == grid.SpacingGeometric {
+		return edge * edge / inner
+	}
+	return edge + (edge - inner)
+}
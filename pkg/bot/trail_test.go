@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/types"
+)
+
+func TestValidateTrailing(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  GridBotConfig
+		wantErr bool
+	}{
+		{name: "Off", config: GridBotConfig{}},
+		{name: "Both with limits", config: GridBotConfig{Trailing: TrailBoth, TrailLowerLimit: 20000, TrailUpperLimit: 40000}},
+		{name: "Unknown mode", config: GridBotConfig{Trailing: "sideways"}, wantErr: true},
+		{name: "Negative limit", config: GridBotConfig{Trailing: TrailUp, TrailUpperLimit: -1}, wantErr: true},
+		{name: "Crossed limits", config: GridBotConfig{Trailing: TrailBoth, TrailLowerLimit: 40000, TrailUpperLimit: 20000}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTrailing(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("validateTrailing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGridBotTrailing(t *testing.T) {
+	type order struct {
+		side  string
+		price float64
+	}
+
+	tests := []struct {
+		name       string
+		trailing   TrailMode
+		upperLimit float64
+		prices     []float64
+		wantLevels []float64
+		wantOrders []order // Open orders, by price
+		refilled   float64 // Price of the buy placed with the funds of the canceled one
+	}{
+		{
+			name:       "Off",
+			trailing:   TrailOff,
+			prices:     []float64{33000, 36000},
+			wantLevels: []float64{25000, 27500, 30000, 32500, 35000},
+			wantOrders: []order{{"BUY", 25000}, {"BUY", 27500}, {"BUY", 30000}, {"BUY", 32500}},
+		},
+		{
+			name:       "Up one level",
+			trailing:   TrailUp,
+			prices:     []float64{33000, 36000},
+			wantLevels: []float64{27500, 30000, 32500, 35000, 37500},
+			wantOrders: []order{{"BUY", 27500}, {"BUY", 30000}, {"BUY", 32500}, {"BUY", 35000}},
+			refilled:   35000,
+		},
+		{
+			name:       "Up two levels",
+			trailing:   TrailBoth,
+			prices:     []float64{33000, 36000, 39000},
+			wantLevels: []float64{30000, 32500, 35000, 37500, 40000},
+			wantOrders: []order{{"BUY", 30000}, {"BUY", 32500}, {"BUY", 35000}, {"BUY", 37500}},
+			refilled:   37500,
+		},
+		{
+			name:       "Up to the limit",
+			trailing:   TrailUp,
+			upperLimit: 38000,
+			prices:     []float64{33000, 36000, 39000},
+			wantLevels: []float64{27500, 30000, 32500, 35000, 37500},
+			wantOrders: []order{{"BUY", 27500}, {"BUY", 30000}, {"BUY", 32500}, {"BUY", 35000}},
+			refilled:   35000,
+		},
+		{
+			name:       "Down only ignores a breakout up",
+			trailing:   TrailDown,
+			prices:     []float64{33000, 36000},
+			wantLevels: []float64{25000, 27500, 30000, 32500, 35000},
+			wantOrders: []order{{"BUY", 25000}, {"BUY", 27500}, {"BUY", 30000}, {"BUY", 32500}},
+		},
+		{
+			name:       "Down one level",
+			trailing:   TrailDown,
+			prices:     []float64{27000, 24000},
+			wantLevels: []float64{22500, 25000, 27500, 30000, 32500},
+			wantOrders: []order{{"SELL", 25000}, {"SELL", 27500}, {"SELL", 30000}, {"SELL", 32500}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GridBotConfig{
+				Symbol:          "BTCUSDT",
+				LowerPrice:      25000.0,
+				UpperPrice:      35000.0,
+				GridNum:         5,
+				Investment:      1000.0,
+				PollInterval:    time.Hour,
+				Trailing:        tt.trailing,
+				TrailUpperLimit: tt.upperLimit,
+			}
+
+			sim, err := exchange.NewSimulatedExchange(exchange.SimulatorConfig{
+				Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+				Balances: map[string]float64{"USDT": 1000, "BTC": 1},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create simulated exchange: %v", err)
+			}
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			sim.SetPrice(start, 31000.0)
+
+			bot, err := NewGridBot(sim, config)
+			if err != nil {
+				t.Fatalf("Failed to create bot: %v", err)
+			}
+
+			ctx := context.Background()
+			if err := bot.Start(ctx); err != nil {
+				t.Fatalf("Failed to start bot: %v", err)
+			}
+			defer bot.Stop(ctx)
+
+			for i, price := range tt.prices {
+				sim.SetPrice(start.Add(time.Duration(i+1)*time.Minute), price)
+				bot.CheckOrders(ctx)
+				bot.UpdatePrice(ctx, price)
+			}
+
+			status := bot.GetStatus()
+			var levels []float64
+			for _, level := range status.Levels {
+				levels = append(levels, level.Price)
+			}
+			if !slices.Equal(levels, tt.wantLevels) {
+				t.Errorf("Levels = %v, want %v", levels, tt.wantLevels)
+			}
+
+			open, err := sim.GetOpenOrders(ctx, config.Symbol)
+			if err != nil {
+				t.Fatalf("GetOpenOrders() error = %v", err)
+			}
+			var orders []order
+			for _, info := range open {
+				orders = append(orders, order{info.Side, info.Price})
+				if info.Price == tt.refilled {
+					// The quote of the canceled 200 USDT buy is placed again
+					assertClose(t, "Quote of the order placed after trailing", info.Price*info.Quantity, 200)
+				}
+			}
+			slices.SortFunc(orders, func(a, b order) int {
+				return int(a.price - b.price)
+			})
+			if !slices.Equal(orders, tt.wantOrders) {
+				t.Errorf("Open orders = %v, want %v", orders, tt.wantOrders)
+			}
+
+			// Every open order is tracked at its level
+			for _, level := range status.Levels {
+				found := slices.ContainsFunc(tt.wantOrders, func(o order) bool {
+					return o.price == level.Price
+				})
+				if found != (level.OrderID != "") {
+					t.Errorf("Level %.2f tracks order %q, want an order: %v", level.Price, level.OrderID, found)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+)
+
+// triggerTimeout bounds the order cancellations and market order placed when
+// a stop-loss or take-profit fires
+const triggerTimeout = 30 * time.Second
+
+// StopAction determines what happens to the grid's holdings when a
+// stop-loss or take-profit fires, after all grid orders are canceled
+type StopAction string
+
+const (
+	// StopActionNone keeps the holdings as they are
+	StopActionNone StopAction = "none"
+	// StopActionSell market sells the grid's base asset inventory
+	StopActionSell StopAction = "sell"
+	// StopActionBuy market buys base asset with the quote currency the
+	// canceled buy orders had reserved
+	StopActionBuy StopAction = "buy"
+)
+
+// ParseStopAction converts an action name into a StopAction
+func ParseStopAction(name string) (StopAction, error) {
+	switch action := StopAction(name); action {
+	case StopActionNone, StopActionSell, StopActionBuy:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown stop action %q, expected %q, %q or %q",
+		name, StopActionNone, StopActionSell, StopActionBuy)
+}
+
+// StopReason records why the bot stopped
+type StopReason string
+
+const (
+	// StopReasonManual means Stop was called
+	StopReasonManual StopReason = "manual"
+	// StopReasonStopLoss means the price fell to the stop-loss price
+	StopReasonStopLoss StopReason = "stop-loss"
+	// StopReasonTakeProfit means the price rose to the take-profit price
+	StopReasonTakeProfit StopReason = "take-profit"
+)
+
+// validateTriggers checks the stop-loss and take-profit configuration
+func validateTriggers(config GridBotConfig) error {
+	if config.StopLossPrice < 0 || config.TakeProfitPrice < 0 {
+		return fmt.Errorf("stop-loss and take-profit prices must not be negative")
+	}
+	if config.StopLossPrice > 0 && config.TakeProfitPrice > 0 && config.StopLossPrice >= config.TakeProfitPrice {
+		return fmt.Errorf("stop-loss price %.2f must be below the take-profit price %.2f",
+			config.StopLossPrice, config.TakeProfitPrice)
+	}
+	for _, action := range []StopAction{config.StopLossAction, config.TakeProfitAction} {
+		if action == "" {
+			continue
+		}
+		if _, err := ParseStopAction(string(action)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crossedTrigger returns the trigger price has crossed, if any
+func (b *GridBot) crossedTrigger(price float64) (StopReason, StopAction, bool) {
+	switch {
+	case b.config.StopLossPrice > 0 && price <= b.config.StopLossPrice:
+		return StopReasonStopLoss, b.config.StopLossAction, true
+	case b.config.TakeProfitPrice > 0 && price >= b.config.TakeProfitPrice:
+		return StopReasonTakeProfit, b.config.TakeProfitAction, true
+	}
+	return "", "", false
+}
+
+// triggerStop stops the bot after a stop-loss or take-profit fired at price:
+// it cancels all grid orders, applies the action to the holdings and records
+// the reason. When the watch loop detected the trigger it runs on its own
+// goroutine, since stopping waits for the loop to exit.
+func (b *GridBot) triggerStop(reason StopReason, action StopAction, price float64) {
+	b.lifecycle.Lock()
+	defer b.lifecycle.Unlock()
+
+	b.mu.RLock()
+	state := b.state
+	var reserved float64 // Quote currency held by buy orders
+	for _, order := range b.orders {
+		if order.order.Side == "BUY" {
+			reserved += order.order.Price * order.order.Quantity
+		}
+	}
+	b.mu.RUnlock()
+	if state != RunStateRunning && state != RunStatePaused {
+		// Stopped in the meantime
+		return
+	}
+	b.setState(RunStateStopping, nil)
+
+	b.logger.Warn("Stopping grid", "reason", reason, "price", price,
+		"stopLossPrice", b.config.StopLossPrice, "takeProfitPrice", b.config.TakeProfitPrice, "action", action)
+
+	ctx, cancel := context.WithTimeout(context.Background(), triggerTimeout)
+	defer cancel()
+
+	var stopErr error
+	if err := b.halt(ctx); err != nil {
+		stopErr = fmt.Errorf("failed to cancel all grid orders: %w", err)
+	}
+	if err := b.closePosition(ctx, action, price, reserved); err != nil {
+		b.logger.Error("Failed to close position", "reason", reason, "action", action, "error", err)
+		if stopErr == nil {
+			stopErr = fmt.Errorf("failed to close position: %w", err)
+		}
+	}
+
+	b.markStopped(reason, stopErr)
+	b.saveState()
+}
+
+// closePosition applies action to the grid's holdings at around price.
+// reserved is the quote currency the canceled buy orders had reserved.
+func (b *GridBot) closePosition(ctx context.Context, action StopAction, price, reserved float64) error {
+	var side string
+	var quantity float64
+	switch action {
+	case StopActionSell:
+		b.mu.RLock()
+		quantity = b.ledger.Inventory
+		b.mu.RUnlock()
+		if base, _, ok := b.assets(); ok {
+			available, err := b.exchange.GetBalance(ctx, base)
+			if err != nil {
+				return fmt.Errorf("failed to get %s balance: %w", base, err)
+			}
+			quantity = math.Min(quantity, available)
+		}
+		side = "SELL"
+	case StopActionBuy:
+		quantity = reserved / price
+		side = "BUY"
+	default:
+		return nil
+	}
+
+	quantity = grid.FloorToStep(quantity, b.symbolInfo.StepSize)
+	if quantity <= 0 || quantity < b.symbolInfo.MinQty || quantity*price < b.symbolInfo.MinNotional {
+		b.logger.Info("Position too small to close", "side", side, "qty", quantity, "price", price)
+		return nil
+	}
+
+	orderID, err := b.exchange.PlaceOrder(ctx, types.Order{
+		Symbol:   b.config.Symbol,
+		Side:     side,
+		Type:     "MARKET",
+		Quantity: quantity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to place market %s order: %w", side, err)
+	}
+
+	// Market orders are not tracked, so book them at the trigger price with
+	// an estimated fee
+	b.mu.Lock()
+	if side == "SELL" {
+		b.ledger.liquidate(price, quantity)
+	} else {
+		b.ledger.open(quantity, price)
+	}
+	b.ledger.addEstimatedFee(quantity * price * b.config.Fees.Taker())
+	b.mu.Unlock()
+
+	b.logger.Info("Closed position at market", "orderID", orderID, "side", side, "price", price, "qty", quantity)
+	return nil
+}
+
+// markStopped records why and when the bot stopped, moves it to the stopped
+// state, or the error state if err is not nil, and signals Stopped
+func (b *GridBot) markStopped(reason StopReason, err error) {
+	if err != nil {
+		b.setState(RunStateError, err)
+	} else {
+		b.setState(RunStateStopped, nil)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopReason = reason
+	b.stoppedAt = time.Now()
+	select {
+	case <-b.stopped:
+	default:
+		close(b.stopped)
+	}
+}
+
+// Stopped returns a channel that is closed when the running bot stops,
+// whether through Stop or a stop-loss or take-profit
+func (b *GridBot) Stopped() <-chan struct{} {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.stopped
+}
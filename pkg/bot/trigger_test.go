@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+)
+
+func TestValidateTriggers(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  GridBotConfig
+		wantErr bool
+	}{
+		{name: "Off", config: GridBotConfig{}},
+		{name: "Both set", config: GridBotConfig{StopLossPrice: 24000, TakeProfitPrice: 36000, StopLossAction: StopActionSell}},
+		{name: "Negative price", config: GridBotConfig{StopLossPrice: -1}, wantErr: true},
+		{name: "Stop-loss above take-profit", config: GridBotConfig{StopLossPrice: 36000, TakeProfitPrice: 24000}, wantErr: true},
+		{name: "Unknown action", config: GridBotConfig{TakeProfitPrice: 36000, TakeProfitAction: "hold"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTriggers(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("validateTriggers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// marketOrders returns the market orders placed on the mock exchange, which
+// have no price
+func (m *mockExchange) marketOrders() []mockOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var orders []mockOrder
+	for _, order := range m.orders {
+		if order.price == 0 {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+func TestGridBotStopTriggers(t *testing.T) {
+	tests := []struct {
+		name         string
+		stopLoss     float64
+		takeProfit   float64
+		action       StopAction
+		price        float64
+		wantReason   StopReason
+		wantMarket   string // Side of the market order closing the position, if any
+		wantQuantity func(initial PnL) float64
+	}{
+		{
+			name:       "Stop-loss sells the inventory",
+			stopLoss:   24500.0,
+			action:     StopActionSell,
+			price:      24000.0,
+			wantReason: StopReasonStopLoss,
+			wantMarket: "SELL",
+			wantQuantity: func(initial PnL) float64 {
+				return initial.Inventory
+			},
+		},
+		{
+			name:       "Take-profit only cancels",
+			takeProfit: 36000.0,
+			action:     StopActionNone,
+			price:      36500.0,
+			wantReason: StopReasonTakeProfit,
+		},
+		{
+			name:       "Take-profit buys with the reserved quote",
+			takeProfit: 36000.0,
+			action:     StopActionBuy,
+			price:      36000.0,
+			wantReason: StopReasonTakeProfit,
+			wantMarket: "BUY",
+			wantQuantity: func(initial PnL) float64 {
+				// Two buy orders of 200 USDT each
+				return 400.0 / 36000.0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GridBotConfig{
+				Symbol:           "BTCUSDT",
+				LowerPrice:       25000.0,
+				UpperPrice:       35000.0,
+				GridNum:          5,
+				Investment:       1000.0,
+				PollInterval:     time.Hour,
+				Fees:             grid.Fees{MakerRate: 0.001, TakerRate: 0.001},
+				StopLossPrice:    tt.stopLoss,
+				TakeProfitPrice:  tt.takeProfit,
+				StopLossAction:   tt.action,
+				TakeProfitAction: tt.action,
+			}
+
+			exchange := &priceStreamingExchange{
+				mockExchange: &mockExchange{
+					currentPrice: 31000.0,
+					orders:       make(map[string]mockOrder),
+				},
+				prices: make(chan types.PriceUpdate),
+			}
+
+			bot, err := NewGridBot(exchange, config)
+			if err != nil {
+				t.Fatalf("Failed to create bot: %v", err)
+			}
+
+			ctx := context.Background()
+			if err := bot.Start(ctx); err != nil {
+				t.Fatalf("Failed to start bot: %v", err)
+			}
+			initial := bot.PnL()
+
+			exchange.prices <- types.PriceUpdate{Symbol: config.Symbol, Price: tt.price}
+			select {
+			case <-bot.Stopped():
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for the bot to stop")
+			}
+
+			status := bot.GetStatus()
+			if status.Running || status.StopReason != tt.wantReason || status.StoppedAt == nil {
+				t.Errorf("Expected a bot stopped by %s, got running %v, reason %q, stopped at %v",
+					tt.wantReason, status.Running, status.StopReason, status.StoppedAt)
+			}
+			if status.OpenOrders != 0 {
+				t.Errorf("Expected all grid orders canceled, %d left", status.OpenOrders)
+			}
+
+			market := exchange.marketOrders()
+			if tt.wantMarket == "" {
+				if len(market) != 0 {
+					t.Errorf("Expected no market order, got %+v", market)
+				}
+				return
+			}
+			if len(market) != 1 || market[0].side != tt.wantMarket {
+				t.Fatalf("Expected one market %s order, got %+v", tt.wantMarket, market)
+			}
+			assertClose(t, "Market order quantity", market[0].quantity, tt.wantQuantity(initial))
+
+			pnl := bot.PnL()
+			if tt.wantMarket == "SELL" && pnl.Inventory != 0 {
+				t.Errorf("Expected no inventory after selling, got %v", pnl.Inventory)
+			}
+			if pnl.EstimatedFees <= initial.EstimatedFees {
+				t.Error("Expected the market order's fee to be booked")
+			}
+		})
+	}
+}
+
+func TestGridBotStartPastTrigger(t *testing.T) {
+	config := GridBotConfig{
+		Symbol:        "BTCUSDT",
+		LowerPrice:    25000.0,
+		UpperPrice:    35000.0,
+		GridNum:       5,
+		Investment:    1000.0,
+		PollInterval:  time.Hour,
+		StopLossPrice: 32000.0,
+	}
+
+	exchange := &mockExchange{
+		currentPrice: 31000.0,
+		orders:       make(map[string]mockOrder),
+	}
+
+	bot, err := NewGridBot(exchange, config)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	if err := bot.Start(context.Background()); err == nil {
+		t.Fatal("Expected Start to fail below the stop-loss price")
+	}
+	if len(exchange.orders) != 0 || bot.GetStatus().Running {
+		t.Error("Expected no orders and a stopped bot")
+	}
+}
@@ -0,0 +1,34 @@
+package exchange
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	minReconnectDelay = time.Second // First delay before reconnecting a stream
+	maxReconnectDelay = time.Minute // Upper bound for the reconnect delay
+)
+
+// nextBackoff doubles the delay, capped at maxReconnectDelay
+func nextBackoff(delay time.Duration) time.Duration {
+	delay *= 2
+	if delay > maxReconnectDelay {
+		return maxReconnectDelay
+	}
+	return delay
+}
+
+// sleepContext waits for the given duration or until ctx is canceled.
+// It returns false if ctx was canceled.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
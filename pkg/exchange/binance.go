@@ -2,17 +2,31 @@ package exchange
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"sync"
+	"time"
 
 	"spot_grid_bot/pkg/types"
 
 	"github.com/adshao/go-binance/v2"
 )
 
-// BinanceClient wraps the Binance API client with testnet support
+// BinanceClient wraps the Binance API client with testnet support. Requests
+// are throttled to stay under the exchange's RateLimits, failed requests are
+// retried according to its RetryPolicy and errors are wrapped in the types
+// error that matches them, such as types.ErrRateLimited.
 type BinanceClient struct {
-	client *binance.Client
+	client      *binance.Client
+	logger      *slog.Logger
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+	transport   *limitingTransport // Transport of client's HTTP client
+	mu          sync.Mutex
+	symbols     map[string]types.SymbolInfo // Cached trading rules by symbol
+	userStream  time.Time                   // When the user data stream last connected, zero while it is down
 }
 
 // NewBinanceClient creates a new Binance client configured for testnet
@@ -23,16 +37,43 @@ func NewBinanceClient(apiKey, apiSecret string) (*BinanceClient, error) {
 
 	// Use testnet
 	binance.UseTestnet = true
-	client := binance.NewClient(apiKey, apiSecret)
+	return newBinanceClient(binance.NewClient(apiKey, apiSecret)), nil
+}
 
-	return &BinanceClient{
-		client: client,
-	}, nil
+// NewPublicBinanceClient creates a testnet client without credentials. It can
+// only use the public market data endpoints, such as prices and symbol info.
+func NewPublicBinanceClient() *BinanceClient {
+	binance.UseTestnet = true
+	return newBinanceClient(binance.NewClient("", ""))
+}
+
+// newBinanceClient wraps client with the default retry policy and rate limits
+func newBinanceClient(client *binance.Client) *BinanceClient {
+	c := &BinanceClient{
+		client:      client,
+		logger:      slog.Default(),
+		retryPolicy: defaultRetryPolicy,
+		limiter:     newRateLimiter(defaultRateLimits, slog.Default()),
+		symbols:     make(map[string]types.SymbolInfo),
+	}
+	c.limitRequests()
+	return c
+}
+
+// SetLogger makes the client log stream events, retries and throttling to
+// logger instead of the default logger
+func (c *BinanceClient) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+	c.limiter.logger = logger
 }
 
 // GetSymbolPrice gets the current price for a symbol
 func (c *BinanceClient) GetSymbolPrice(ctx context.Context, symbol string) (float64, error) {
-	prices, err := c.client.NewListPricesService().Symbol(symbol).Do(ctx)
+	var prices []*binance.SymbolPrice
+	err := c.retry(ctx, "get price", types.IsRetryable, func() (err error) {
+		prices, err = c.client.NewListPricesService().Symbol(symbol).Do(ctx)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get price: %w", err)
 	}
@@ -49,29 +90,77 @@ func (c *BinanceClient) GetSymbolPrice(ctx context.Context, symbol string) (floa
 	return price, nil
 }
 
-// PlaceOrder places a new order
+// PlaceOrder places a new order, rounded to the symbol's tick and step size
 func (c *BinanceClient) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	info, err := c.GetSymbolInfo(ctx, order.Symbol)
+	if err != nil {
+		return "", err
+	}
+	if order.Type == "MARKET" && order.Price <= 0 && info.MinNotional > 0 {
+		// The minimum notional of a market order is checked at the last price
+		if order.Price, err = c.GetSymbolPrice(ctx, order.Symbol); err != nil {
+			return "", err
+		}
+	}
+	priceStr, quantityStr, err := applyFilters(order, info)
+	if err != nil {
+		return "", fmt.Errorf("%w by symbol filters: %w", types.ErrOrderRejected, err)
+	}
+
 	service := c.client.NewCreateOrderService().
 		Symbol(order.Symbol).
 		Side(binance.SideType(order.Side)).
 		Type(binance.OrderType(order.Type))
 
-	// Convert quantity to string with appropriate precision
-	quantityStr := strconv.FormatFloat(order.Quantity, 'f', 8, 64)
+	if order.ClientOrderID != "" {
+		service.NewClientOrderID(order.ClientOrderID)
+	}
+
 	service.Quantity(quantityStr)
 
 	if order.Type == "LIMIT" {
-		priceStr := strconv.FormatFloat(order.Price, 'f', 8, 64)
 		service.TimeInForce(binance.TimeInForceType(order.TimeInForce)).
 			Price(priceStr)
 	}
 
-	resp, err := service.Do(ctx)
+	// A request that failed with an unknown outcome may still have placed the
+	// order. With a client order ID the exchange rejects the retry as a
+	// duplicate and the order is looked up instead; without one only requests
+	// that were turned away by the rate limits are retried.
+	retryable := types.IsRetryable
+	if order.ClientOrderID == "" {
+		retryable = func(err error) bool {
+			return errors.Is(err, types.ErrRateLimited)
+		}
+	}
+
+	var orderID int64
+	attempts := 0
+	err = c.retry(ctx, "place order", retryable, func() error {
+		attempts++
+		resp, err := service.Do(ctx)
+		if err == nil {
+			orderID = resp.OrderID
+			return nil
+		}
+		if attempts == 1 || !errors.Is(classify(err), types.ErrDuplicateOrder) {
+			return err
+		}
+		placed, lookupErr := c.client.NewGetOrderService().
+			Symbol(order.Symbol).
+			OrigClientOrderID(order.ClientOrderID).
+			Do(ctx)
+		if lookupErr != nil {
+			return err
+		}
+		orderID = placed.OrderID
+		return nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to place order: %w", err)
 	}
 
-	return strconv.FormatInt(resp.OrderID, 10), nil
+	return strconv.FormatInt(orderID, 10), nil
 }
 
 // CancelOrder cancels an existing order
@@ -82,11 +171,13 @@ func (c *BinanceClient) CancelOrder(ctx context.Context, symbol, orderID string)
 		return fmt.Errorf("invalid order ID format: %w", err)
 	}
 
-	_, err = c.client.NewCancelOrderService().
-		Symbol(symbol).
-		OrderID(orderIDInt).
-		Do(ctx)
-
+	err = c.retry(ctx, "cancel order", types.IsRetryable, func() error {
+		_, err := c.client.NewCancelOrderService().
+			Symbol(symbol).
+			OrderID(orderIDInt).
+			Do(ctx)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to cancel order: %w", err)
 	}
@@ -96,7 +187,11 @@ func (c *BinanceClient) CancelOrder(ctx context.Context, symbol, orderID string)
 
 // GetBalance gets the balance for a specific asset
 func (c *BinanceClient) GetBalance(ctx context.Context, asset string) (float64, error) {
-	account, err := c.client.NewGetAccountService().Do(ctx)
+	var account *binance.Account
+	err := c.retry(ctx, "get account", types.IsRetryable, func() (err error) {
+		account, err = c.client.NewGetAccountService().Do(ctx)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get account info: %w", err)
 	}
@@ -113,3 +208,93 @@ func (c *BinanceClient) GetBalance(ctx context.Context, asset string) (float64,
 
 	return 0, fmt.Errorf("asset %s not found", asset)
 }
+
+// GetOrder gets the current state of an order
+func (c *BinanceClient) GetOrder(ctx context.Context, symbol, orderID string) (types.OrderInfo, error) {
+	orderIDInt, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return types.OrderInfo{}, fmt.Errorf("invalid order ID format: %w", err)
+	}
+
+	var order *binance.Order
+	err = c.retry(ctx, "get order", types.IsRetryable, func() (err error) {
+		order, err = c.client.NewGetOrderService().
+			Symbol(symbol).
+			OrderID(orderIDInt).
+			Do(ctx)
+		return err
+	})
+	if err != nil {
+		return types.OrderInfo{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return toOrderInfo(order)
+}
+
+// GetOpenOrders lists all open orders for a symbol
+func (c *BinanceClient) GetOpenOrders(ctx context.Context, symbol string) ([]types.OrderInfo, error) {
+	var orders []*binance.Order
+	err := c.retry(ctx, "list open orders", types.IsRetryable, func() (err error) {
+		orders, err = c.client.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open orders: %w", err)
+	}
+
+	infos := make([]types.OrderInfo, 0, len(orders))
+	for _, order := range orders {
+		info, err := toOrderInfo(order)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// toOrderInfo converts a Binance order into an OrderInfo
+func toOrderInfo(order *binance.Order) (types.OrderInfo, error) {
+	price, err := strconv.ParseFloat(order.Price, 64)
+	if err != nil {
+		return types.OrderInfo{}, fmt.Errorf("failed to parse order price: %w", err)
+	}
+	quantity, err := strconv.ParseFloat(order.OrigQuantity, 64)
+	if err != nil {
+		return types.OrderInfo{}, fmt.Errorf("failed to parse order quantity: %w", err)
+	}
+	executed, err := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	if err != nil {
+		return types.OrderInfo{}, fmt.Errorf("failed to parse executed quantity: %w", err)
+	}
+	var average float64
+	if executed > 0 {
+		quote, err := strconv.ParseFloat(order.CummulativeQuoteQuantity, 64)
+		if err != nil {
+			return types.OrderInfo{}, fmt.Errorf("failed to parse cumulative quote quantity: %w", err)
+		}
+		average = quote / executed
+	}
+
+	return types.OrderInfo{
+		OrderID:          strconv.FormatInt(order.OrderID, 10),
+		ClientOrderID:    order.ClientOrderID,
+		Symbol:           order.Symbol,
+		Side:             string(order.Side),
+		Price:            price,
+		Quantity:         quantity,
+		ExecutedQuantity: executed,
+		AveragePrice:     average,
+		Status:           toOrderStatus(string(order.Status)),
+	}, nil
+}
+
+// toOrderStatus converts a Binance order status into an OrderStatus
+func toOrderStatus(status string) types.OrderStatus {
+	if status == string(binance.OrderStatusExpiredInMatch) {
+		// Orders expired by self-trade prevention are treated as expired
+		return types.OrderStatusExpired
+	}
+	return types.OrderStatus(status)
+}
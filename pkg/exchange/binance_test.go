@@ -2,8 +2,14 @@ package exchange
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"spot_grid_bot/pkg/types"
 )
@@ -189,3 +195,212 @@ func TestGetBalance(t *testing.T) {
 		})
 	}
 }
+
+// newTestClient returns a client whose REST calls are served by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *BinanceClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewBinanceClient("test_api_key", "test_api_secret")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.client.BaseURL = server.URL
+	return client
+}
+
+func TestGetOpenOrders(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/openOrders" || r.URL.Query().Get("symbol") != "BTCUSDT" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"symbol":"BTCUSDT","orderId":42,"clientOrderId":"grid_1_B_abc","price":"27500.00",
+			"origQty":"0.00100000","executedQty":"0.00040000","cummulativeQuoteQty":"11.00000000",
+			"status":"PARTIALLY_FILLED","side":"BUY"}]`))
+	})
+
+	orders, err := client.GetOpenOrders(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetOpenOrders() error = %v", err)
+	}
+
+	executed := 0.0004
+	want := []types.OrderInfo{{
+		OrderID:          "42",
+		ClientOrderID:    "grid_1_B_abc",
+		Symbol:           "BTCUSDT",
+		Side:             "BUY",
+		Price:            27500.0,
+		Quantity:         0.001,
+		ExecutedQuantity: executed,
+		AveragePrice:     11 / executed,
+		Status:           types.OrderStatusPartiallyFilled,
+	}}
+	if !reflect.DeepEqual(orders, want) {
+		t.Errorf("GetOpenOrders() = %+v, want %+v", orders, want)
+	}
+}
+
+// recordingObserver records observed requests
+type recordingObserver struct {
+	requests []string
+	errors   int
+}
+
+func (o *recordingObserver) ObserveRequest(method, endpoint string, duration time.Duration, err error) {
+	o.requests = append(o.requests, method+" "+endpoint)
+	if err != nil {
+		o.errors++
+	}
+}
+
+func TestRequestObserver(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/ticker/price" {
+			http.Error(w, `{"code":-1121,"msg":"Invalid symbol."}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`[{"symbol":"BTCUSDT","price":"30000.00"}]`))
+	})
+	observer := &recordingObserver{}
+	client.SetRequestObserver(observer)
+
+	ctx := context.Background()
+	if _, err := client.GetSymbolPrice(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("GetSymbolPrice() error = %v", err)
+	}
+	if _, err := client.GetOpenOrders(ctx, "BTCUSDT"); err == nil {
+		t.Fatal("Expected GetOpenOrders() to fail")
+	}
+
+	want := []string{"GET /api/v3/ticker/price", "GET /api/v3/openOrders"}
+	if !reflect.DeepEqual(observer.requests, want) {
+		t.Errorf("Observed requests = %v, want %v", observer.requests, want)
+	}
+	if observer.errors != 1 {
+		t.Errorf("Observed %d errors, want 1", observer.errors)
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Error("SetRequestObserver modified the default HTTP client")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	type response struct {
+		status int
+		body   string
+	}
+	var (
+		price       = response{http.StatusOK, `[{"symbol":"BTCUSDT","price":"30000.00"}]`}
+		rateLimited = response{http.StatusTooManyRequests, `{"code":-1003,"msg":"Too much request weight used."}`}
+		badGateway  = response{http.StatusBadGateway, `<html>502 Bad Gateway</html>`}
+		timeout     = response{http.StatusInternalServerError, `{"code":-1007,"msg":"Timeout waiting for response from backend server."}`}
+		duplicate   = response{http.StatusBadRequest, `{"code":-2010,"msg":"Duplicate order sent."}`}
+		noBalance   = response{http.StatusBadRequest, `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`}
+		placed      = response{http.StatusOK, `{"symbol":"BTCUSDT","orderId":7}`}
+		lookedUp    = response{http.StatusOK, `{"symbol":"BTCUSDT","orderId":42,"clientOrderId":"grid_1_B_abc","price":"27500.00",
+			"origQty":"0.00100000","executedQty":"0.00000000","status":"NEW","side":"BUY"}`}
+	)
+
+	getPrice := func(client *BinanceClient) (string, error) {
+		price, err := client.GetSymbolPrice(context.Background(), "BTCUSDT")
+		return strconv.FormatFloat(price, 'f', -1, 64), err
+	}
+	placeOrder := func(clientOrderID string) func(client *BinanceClient) (string, error) {
+		return func(client *BinanceClient) (string, error) {
+			return client.PlaceOrder(context.Background(), types.Order{
+				Symbol:        "BTCUSDT",
+				Side:          "BUY",
+				Type:          "LIMIT",
+				Quantity:      0.001,
+				Price:         27500.0,
+				TimeInForce:   "GTC",
+				ClientOrderID: clientOrderID,
+			})
+		}
+	}
+
+	tests := []struct {
+		name         string
+		responses    []response // Served in order, the last one repeatedly
+		call         func(client *BinanceClient) (string, error)
+		want         string
+		wantErr      error
+		wantRequests int
+	}{
+		{
+			name:         "Rate limited then served",
+			responses:    []response{rateLimited, rateLimited, price},
+			call:         getPrice,
+			want:         "30000",
+			wantRequests: 3,
+		},
+		{
+			name:         "Unavailable until out of attempts",
+			responses:    []response{badGateway},
+			call:         getPrice,
+			wantErr:      types.ErrUnavailable,
+			wantRequests: 3,
+		},
+		{
+			name:         "Insufficient balance is not retried",
+			responses:    []response{noBalance},
+			call:         placeOrder("grid_1_B_abc"),
+			wantErr:      types.ErrInsufficientBalance,
+			wantRequests: 1,
+		},
+		{
+			name:         "Order placed despite a timeout is looked up",
+			responses:    []response{timeout, duplicate, lookedUp},
+			call:         placeOrder("grid_1_B_abc"),
+			want:         "42",
+			wantRequests: 3,
+		},
+		{
+			name:         "Order without client ID is not retried after a timeout",
+			responses:    []response{timeout, placed},
+			call:         placeOrder(""),
+			wantErr:      types.ErrUnavailable,
+			wantRequests: 1,
+		},
+		{
+			name:         "Order without client ID is retried when rate limited",
+			responses:    []response{rateLimited, placed},
+			call:         placeOrder(""),
+			want:         "7",
+			wantRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				resp := tt.responses[min(requests, len(tt.responses)-1)]
+				requests++
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(resp.status)
+				w.Write([]byte(resp.body))
+			})
+			client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+			client.symbols["BTCUSDT"] = types.SymbolInfo{Symbol: "BTCUSDT", TickSize: 0.01, StepSize: 0.00001}
+
+			got, err := tt.call(client)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			} else if got != tt.want {
+				t.Errorf("Result = %s, want %s", got, tt.want)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Sent %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
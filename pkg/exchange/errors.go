@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"spot_grid_bot/pkg/types"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// Binance API error codes, see
+// https://developers.binance.com/docs/binance-spot-api-docs/errors
+const (
+	codeUnknown          = -1000 // Unknown error while processing the request
+	codeDisconnected     = -1001 // Internal error, unable to process the request
+	codeTooManyRequests  = -1003 // Request weight or IP rate limit exceeded
+	codeUnexpectedResp   = -1006 // Unexpected response from the message bus, status unknown
+	codeTimeout          = -1007 // Timeout waiting for the backend, status unknown
+	codeServerBusy       = -1008 // Server overloaded
+	codeFilterFailure    = -1013 // Order violates a symbol filter
+	codeTooManyOrders    = -1015 // Order rate limit exceeded
+	codeNewOrderRejected = -2010 // Order rejected, the message tells why
+	codeCancelRejected   = -2011 // Cancel rejected, usually an unknown order
+	codeNoSuchOrder      = -2013 // Order does not exist
+)
+
+// classify wraps err in the types error matching the failure, if any. Errors
+// without a matching class are returned unchanged and are not retried.
+func classify(err error) error {
+	if kind := errorKind(err); kind != nil && !errors.Is(err, kind) {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+// errorKind returns the types error for a Binance API or transport error, or
+// nil
+func errorKind(err error) error {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		if !apiErr.IsValid() {
+			// Error responses without a code come from the servers in front
+			// of the API, such as gateway errors
+			return types.ErrUnavailable
+		}
+		return apiErrorKind(apiErr.Code, apiErr.Message)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return types.ErrUnavailable
+	}
+	return nil
+}
+
+// apiErrorKind returns the types error for a Binance error code and message,
+// or nil
+func apiErrorKind(code int64, message string) error {
+	message = strings.ToLower(message)
+
+	switch code {
+	case codeTooManyRequests, codeTooManyOrders:
+		return types.ErrRateLimited
+	case codeUnknown, codeDisconnected, codeUnexpectedResp, codeTimeout, codeServerBusy:
+		return types.ErrUnavailable
+	case codeFilterFailure:
+		return types.ErrOrderRejected
+	case codeNewOrderRejected:
+		switch {
+		case strings.Contains(message, "insufficient balance"):
+			return types.ErrInsufficientBalance
+		case strings.Contains(message, "duplicate order"):
+			return types.ErrDuplicateOrder
+		}
+		return types.ErrOrderRejected
+	case codeCancelRejected:
+		if strings.Contains(message, "unknown order") {
+			return types.ErrUnknownOrder
+		}
+	case codeNoSuchOrder:
+		return types.ErrUnknownOrder
+	}
+	return nil
+}
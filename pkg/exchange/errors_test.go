@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"spot_grid_bot/pkg/types"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		want          error // nil if the error has no class
+		wantRetryable bool
+	}{
+		{
+			name:          "Request weight limit",
+			err:           &common.APIError{Code: -1003, Message: "Too much request weight used; current limit is 6000 request weight per 1 MINUTE."},
+			want:          types.ErrRateLimited,
+			wantRetryable: true,
+		},
+		{
+			name:          "Order rate limit",
+			err:           &common.APIError{Code: -1015, Message: "Too many new orders."},
+			want:          types.ErrRateLimited,
+			wantRetryable: true,
+		},
+		{
+			name:          "Backend timeout",
+			err:           &common.APIError{Code: -1007, Message: "Timeout waiting for response from backend server. Send status unknown; execution status unknown."},
+			want:          types.ErrUnavailable,
+			wantRetryable: true,
+		},
+		{
+			name:          "Gateway error without a code",
+			err:           &common.APIError{Response: []byte("<html>502 Bad Gateway</html>")},
+			want:          types.ErrUnavailable,
+			wantRetryable: true,
+		},
+		{
+			name:          "Network timeout",
+			err:           fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Err: context.DeadlineExceeded}),
+			want:          types.ErrUnavailable,
+			wantRetryable: true,
+		},
+		{
+			name: "Insufficient balance",
+			err:  &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."},
+			want: types.ErrInsufficientBalance,
+		},
+		{
+			name: "Filter failure",
+			err:  &common.APIError{Code: -1013, Message: "Filter failure: NOTIONAL"},
+			want: types.ErrOrderRejected,
+		},
+		{
+			name: "Duplicate order",
+			err:  &common.APIError{Code: -2010, Message: "Duplicate order sent."},
+			want: types.ErrDuplicateOrder,
+		},
+		{
+			name: "Unknown order",
+			err:  &common.APIError{Code: -2011, Message: "Unknown order sent."},
+			want: types.ErrUnknownOrder,
+		},
+		{
+			name: "Invalid symbol",
+			err:  &common.APIError{Code: -1121, Message: "Invalid symbol."},
+		},
+		{
+			name: "Other error",
+			err:  errors.New("failed to parse price"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classify() = %v, want it to wrap %v", err, tt.err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("classify() = %v, want it to wrap %v", err, tt.want)
+			}
+			if tt.want == nil && err != tt.err {
+				t.Errorf("classify() = %v, want the error unchanged", err)
+			}
+			if got := types.IsRetryable(err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, got, tt.wantRetryable)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package exchange
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RequestObserver is notified of every REST request a BinanceClient makes.
+// err is set for transport failures and error responses alike.
+type RequestObserver interface {
+	ObserveRequest(method, endpoint string, duration time.Duration, err error)
+}
+
+// SetRequestObserver makes the client report its REST requests to observer.
+// Requests are timed once the rate limiter let them through.
+func (c *BinanceClient) SetRequestObserver(observer RequestObserver) {
+	c.transport.next = &observingTransport{next: c.transport.next, observer: observer}
+}
+
+// observingTransport times requests and reports them to an observer
+type observingTransport struct {
+	next     http.RoundTripper
+	observer RequestObserver
+}
+
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	observed := err
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		observed = fmt.Errorf("HTTP status %d", resp.StatusCode)
+	}
+	t.observer.ObserveRequest(req.Method, req.URL.Path, time.Since(start), observed)
+	return resp, err
+}
@@ -0,0 +1,269 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+)
+
+// PriceFeed supplies prices for a symbol. BinanceClient provides a live feed
+// and ReplayFeed a recorded one.
+type PriceFeed interface {
+	SubscribePrice(ctx context.Context, symbol string) (<-chan types.PriceUpdate, error)
+}
+
+// PaperExchange trades against a price feed with balances and orders held
+// entirely in memory, so the bot can run end to end without exchange
+// credentials. Limit orders fill in full once the price crosses them. Fills
+// are pushed as execution reports and prices are re-published to the bot.
+type PaperExchange struct {
+	*SimulatedExchange
+
+	mu        sync.Mutex
+	reported  int // Number of simulator fills already published
+	reports   map[*reportQueue]struct{}
+	prices    map[chan types.PriceUpdate]struct{}
+	lastPrice types.PriceUpdate
+}
+
+// NewPaperExchange creates a paper exchange with the given balances. It has
+// no price until SetPrice is called or Run is fed one.
+func NewPaperExchange(config SimulatorConfig) (*PaperExchange, error) {
+	sim, err := NewSimulatedExchange(config)
+	if err != nil {
+		return nil, err
+	}
+	return &PaperExchange{
+		SimulatedExchange: sim,
+		reports:           make(map[*reportQueue]struct{}),
+		prices:            make(map[chan types.PriceUpdate]struct{}),
+	}, nil
+}
+
+// Run applies every update from feed until ctx is canceled or the feed ends.
+// It returns ctx.Err() if canceled and nil when the feed ends.
+func (p *PaperExchange) Run(ctx context.Context, feed PriceFeed) error {
+	updates, err := feed.SubscribePrice(ctx, p.config.Symbol.Symbol)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case update, ok := <-updates:
+			if !ok {
+				return ctx.Err()
+			}
+			p.SetPrice(update)
+		}
+	}
+}
+
+// SetPrice moves the market to update.Price, fills the orders it crosses and
+// publishes the fills and the price to subscribers
+func (p *PaperExchange) SetPrice(update types.PriceUpdate) {
+	if update.Price <= 0 {
+		return
+	}
+	if update.Symbol == "" {
+		update.Symbol = p.config.Symbol.Symbol
+	}
+	if update.Time.IsZero() {
+		update.Time = time.Now()
+	}
+	p.SimulatedExchange.SetPrice(update.Time, update.Price)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.publishFills()
+	p.lastPrice = update
+	for updates := range p.prices {
+		publishPrice(updates, update)
+	}
+}
+
+// PlaceOrder places an order and publishes the fill if it executes at once
+func (p *PaperExchange) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	orderID, err := p.SimulatedExchange.PlaceOrder(ctx, order)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	p.publishFills()
+	p.mu.Unlock()
+	return orderID, nil
+}
+
+// CancelOrder cancels a resting order and publishes the cancellation
+func (p *PaperExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	if err := p.SimulatedExchange.CancelOrder(ctx, symbol, orderID); err != nil {
+		return err
+	}
+	info, err := p.SimulatedExchange.GetOrder(ctx, symbol, orderID)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.publishFills()
+	p.publish(types.ExecutionReport{
+		Symbol:             info.Symbol,
+		OrderID:            info.OrderID,
+		ClientOrderID:      info.ClientOrderID,
+		Side:               info.Side,
+		Status:             info.Status,
+		Price:              info.Price,
+		Quantity:           info.Quantity,
+		CumulativeQuantity: info.ExecutedQuantity,
+		Time:               time.Now(),
+	})
+	p.mu.Unlock()
+	return nil
+}
+
+// SubscribeExecutionReports streams fills and cancellations until ctx is
+// canceled. Reports are queued without limit, so a slow reader never blocks
+// trading. The returned channel is closed when ctx is canceled.
+func (p *PaperExchange) SubscribeExecutionReports(ctx context.Context) (<-chan types.ExecutionReport, error) {
+	queue := &reportQueue{notify: make(chan struct{}, 1)}
+	out := make(chan types.ExecutionReport)
+
+	p.mu.Lock()
+	p.reports[queue] = struct{}{}
+	p.mu.Unlock()
+
+	go func() {
+		defer close(out)
+		defer func() {
+			p.mu.Lock()
+			delete(p.reports, queue)
+			p.mu.Unlock()
+		}()
+		queue.serve(ctx, out)
+	}()
+
+	return out, nil
+}
+
+// SubscribePrice streams the prices applied to the exchange until ctx is
+// canceled. Like the live stream, the channel only holds the latest update.
+func (p *PaperExchange) SubscribePrice(ctx context.Context, symbol string) (<-chan types.PriceUpdate, error) {
+	if err := p.checkSymbol(symbol); err != nil {
+		return nil, err
+	}
+	updates := make(chan types.PriceUpdate, 1)
+
+	p.mu.Lock()
+	p.prices[updates] = struct{}{}
+	if p.lastPrice.Price > 0 {
+		updates <- p.lastPrice
+	}
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		delete(p.prices, updates)
+		close(updates)
+		p.mu.Unlock()
+	}()
+
+	return updates, nil
+}
+
+// publishFills publishes the simulator fills not yet reported. p.mu must be
+// held.
+func (p *PaperExchange) publishFills() {
+	fills := p.fillsFrom(p.reported)
+	p.reported += len(fills)
+	for _, fill := range fills {
+		p.publish(fill)
+	}
+}
+
+// publish queues a report for every subscriber. p.mu must be held.
+func (p *PaperExchange) publish(report types.ExecutionReport) {
+	for queue := range p.reports {
+		queue.push(report)
+	}
+}
+
+// reportQueue buffers execution reports for one subscriber
+type reportQueue struct {
+	mu      sync.Mutex
+	pending []types.ExecutionReport
+	notify  chan struct{} // Signaled when pending grows
+}
+
+// push appends a report without blocking
+func (q *reportQueue) push(report types.ExecutionReport) {
+	q.mu.Lock()
+	q.pending = append(q.pending, report)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// serve delivers queued reports to out in order until ctx is canceled
+func (q *reportQueue) serve(ctx context.Context, out chan<- types.ExecutionReport) {
+	for {
+		q.mu.Lock()
+		pending := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+
+		for _, report := range pending {
+			select {
+			case out <- report:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReplayFeed replays recorded price updates, for example from historical
+// data. Every update is delivered; none are dropped for a slow reader.
+type ReplayFeed struct {
+	Updates  []types.PriceUpdate
+	Interval time.Duration // Pause between updates, zero to replay as fast as they are read
+}
+
+// SubscribePrice streams the recorded updates for symbol and closes the
+// channel when they run out or ctx is canceled. Updates without a symbol are
+// taken to be for the requested one.
+func (f ReplayFeed) SubscribePrice(ctx context.Context, symbol string) (<-chan types.PriceUpdate, error) {
+	updates := make(chan types.PriceUpdate)
+	go func() {
+		defer close(updates)
+		for i, update := range f.Updates {
+			if update.Symbol != "" && update.Symbol != symbol {
+				continue
+			}
+			if i > 0 && f.Interval > 0 && !sleepContext(ctx, f.Interval) {
+				return
+			}
+			update.Symbol = symbol
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
@@ -0,0 +1,118 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+)
+
+func newTestPaperExchange(t *testing.T) *PaperExchange {
+	t.Helper()
+
+	paper, err := NewPaperExchange(SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 10000, "BTC": 1},
+		MakerFee: 0.001,
+	})
+	if err != nil {
+		t.Fatalf("NewPaperExchange() error = %v", err)
+	}
+	return paper
+}
+
+func receiveReport(t *testing.T, reports <-chan types.ExecutionReport) types.ExecutionReport {
+	t.Helper()
+
+	select {
+	case report := <-reports:
+		return report
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for execution report")
+		return types.ExecutionReport{}
+	}
+}
+
+func TestPaperExchangePublishesReports(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	paper := newTestPaperExchange(t)
+	if _, err := paper.GetSymbolPrice(ctx, "BTCUSDT"); err == nil {
+		t.Error("Expected no price before the first update")
+	}
+	paper.SetPrice(types.PriceUpdate{Price: 30000})
+
+	reports, err := paper.SubscribeExecutionReports(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeExecutionReports() error = %v", err)
+	}
+	prices, err := paper.SubscribePrice(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatalf("SubscribePrice() error = %v", err)
+	}
+	if update := <-prices; update.Price != 30000 {
+		t.Errorf("Expected the current price on subscription, got %+v", update)
+	}
+
+	buyID, err := paper.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "LIMIT", Price: 29000, Quantity: 0.1})
+	if err != nil {
+		t.Fatalf("PlaceOrder(BUY) error = %v", err)
+	}
+	sellID, err := paper.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "SELL", Type: "LIMIT", Price: 31000, Quantity: 0.1})
+	if err != nil {
+		t.Fatalf("PlaceOrder(SELL) error = %v", err)
+	}
+
+	paper.SetPrice(types.PriceUpdate{Price: 28500})
+	if update := <-prices; update.Price != 28500 {
+		t.Errorf("Expected price 28500, got %+v", update)
+	}
+	report := receiveReport(t, reports)
+	if report.OrderID != buyID || report.Status != types.OrderStatusFilled || report.LastPrice != 29000 {
+		t.Errorf("Expected the buy to fill at 29000, got %+v", report)
+	}
+
+	if err := paper.CancelOrder(ctx, "BTCUSDT", sellID); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+	report = receiveReport(t, reports)
+	if report.OrderID != sellID || report.Status != types.OrderStatusCanceled {
+		t.Errorf("Expected the sell to be canceled, got %+v", report)
+	}
+
+	cancel()
+	if _, ok := <-reports; ok {
+		t.Error("Expected the report channel to close")
+	}
+}
+
+func TestPaperExchangeRunsReplayFeed(t *testing.T) {
+	ctx := context.Background()
+	paper := newTestPaperExchange(t)
+	paper.SetPrice(types.PriceUpdate{Price: 30000})
+
+	if _, err := paper.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "SELL", Type: "LIMIT", Price: 31000, Quantity: 0.5}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	feed := ReplayFeed{Updates: []types.PriceUpdate{
+		{Price: 30500},
+		{Symbol: "ETHUSDT", Price: 2000}, // Other symbols are skipped
+		{Price: 31200},
+		{Price: 30800},
+	}}
+	if err := paper.Run(ctx, feed); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if price, _ := paper.GetSymbolPrice(ctx, "BTCUSDT"); price != 30800 {
+		t.Errorf("GetSymbolPrice() = %v, want 30800", price)
+	}
+	if fills := paper.Fills(); len(fills) != 1 || fills[0].Side != "SELL" {
+		t.Fatalf("Expected the sell to fill, got %+v", fills)
+	}
+	assertBalance(t, paper.SimulatedExchange, "BTC", 0.5)
+	assertBalance(t, paper.SimulatedExchange, "USDT", 10000+15500-15.5)
+}
@@ -0,0 +1,141 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+// pricePollInterval is how often prices are polled over REST while the
+// WebSocket stream is down
+const pricePollInterval = 5 * time.Second
+
+// wsBookTickerServe opens the book ticker WebSocket; replaced in tests
+var wsBookTickerServe = binance.WsBookTickerServe
+
+// SubscribePrice streams the mid price of symbol from the book ticker
+// WebSocket until ctx is canceled. While the stream is down it reconnects with
+// backoff and polls the REST price endpoint in the meantime. The channel only
+// holds the latest update; stale prices are dropped if the reader falls behind.
+func (c *BinanceClient) SubscribePrice(ctx context.Context, symbol string) (<-chan types.PriceUpdate, error) {
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+
+	updates := make(chan types.PriceUpdate, 1)
+	go c.servePrice(ctx, symbol, updates)
+
+	return updates, nil
+}
+
+// servePrice keeps the price stream connected until ctx is canceled
+func (c *BinanceClient) servePrice(ctx context.Context, symbol string, updates chan types.PriceUpdate) {
+	defer close(updates)
+	logger := c.logger.With("symbol", symbol)
+
+	handler := func(event *binance.WsBookTickerEvent) {
+		bid, err := parseDecimal(event.BestBidPrice)
+		if err != nil {
+			logger.Warn("Failed to parse bid price", "error", err)
+			return
+		}
+		ask, err := parseDecimal(event.BestAskPrice)
+		if err != nil {
+			logger.Warn("Failed to parse ask price", "error", err)
+			return
+		}
+		if bid <= 0 || ask <= 0 {
+			return
+		}
+		publishPrice(updates, types.PriceUpdate{
+			Symbol: symbol,
+			Bid:    bid,
+			Ask:    ask,
+			Price:  (bid + ask) / 2,
+			Time:   time.Now(),
+		})
+	}
+
+	delay := minReconnectDelay
+	for {
+		errC := make(chan error, 1)
+		errHandler := func(err error) {
+			select {
+			case errC <- err:
+			default:
+			}
+		}
+
+		doneC, stopC, err := wsBookTickerServe(symbol, handler, errHandler)
+		if err != nil {
+			logger.Warn("Failed to connect price stream", "error", err)
+		} else {
+			delay = minReconnectDelay
+			select {
+			case <-ctx.Done():
+				close(stopC)
+				<-doneC
+				return
+			case <-doneC:
+				select {
+				case err := <-errC:
+					logger.Warn("Price stream disconnected", "error", err)
+				default:
+					logger.Warn("Price stream disconnected")
+				}
+			}
+		}
+
+		// Fall back to REST polling until the next reconnect attempt
+		if !c.pollPrice(ctx, symbol, delay, updates) {
+			return
+		}
+		delay = nextBackoff(delay)
+	}
+}
+
+// pollPrice publishes REST prices for the given duration. It returns false if
+// ctx was canceled.
+func (c *BinanceClient) pollPrice(ctx context.Context, symbol string, d time.Duration, updates chan types.PriceUpdate) bool {
+	deadline := time.Now().Add(d)
+	for {
+		price, err := c.GetSymbolPrice(ctx, symbol)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			c.logger.Warn("Failed to poll price", "symbol", symbol, "error", err)
+		} else {
+			publishPrice(updates, types.PriceUpdate{Symbol: symbol, Price: price, Time: time.Now()})
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return ctx.Err() == nil
+		}
+		if wait > pricePollInterval {
+			wait = pricePollInterval
+		}
+		if !sleepContext(ctx, wait) {
+			return false
+		}
+	}
+}
+
+// publishPrice replaces any unread update with the latest one so the reader
+// never blocks the stream. updates must have a buffer of one and a single sender.
+func publishPrice(updates chan types.PriceUpdate, update types.PriceUpdate) {
+	select {
+	case updates <- update:
+	default:
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- update
+	}
+}
@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+func TestSubscribePriceStream(t *testing.T) {
+	client, err := NewBinanceClient("test_api_key", "test_api_secret")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	original := wsBookTickerServe
+	defer func() { wsBookTickerServe = original }()
+	wsBookTickerServe = func(symbol string, handler binance.WsBookTickerHandler, errHandler binance.ErrHandler) (chan struct{}, chan struct{}, error) {
+		doneC, stopC := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(doneC)
+			handler(&binance.WsBookTickerEvent{
+				Symbol:       symbol,
+				BestBidPrice: "29990.00",
+				BestAskPrice: "30010.00",
+			})
+			<-stopC
+		}()
+		return doneC, stopC, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := client.SubscribePrice(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatalf("SubscribePrice() error = %v", err)
+	}
+
+	select {
+	case update := <-updates:
+		if update.Price != 30000.0 || update.Bid != 29990.0 || update.Ask != 30010.0 {
+			t.Errorf("Unexpected price update %+v", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for price update")
+	}
+
+	cancel()
+	for range updates {
+	}
+}
+
+func TestSubscribePriceFallsBackToPolling(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/ticker/price" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"symbol":"BTCUSDT","price":"31000.00"}]`))
+	})
+
+	original := wsBookTickerServe
+	defer func() { wsBookTickerServe = original }()
+	wsBookTickerServe = func(symbol string, handler binance.WsBookTickerHandler, errHandler binance.ErrHandler) (chan struct{}, chan struct{}, error) {
+		return nil, nil, errors.New("stream unavailable")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := client.SubscribePrice(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatalf("SubscribePrice() error = %v", err)
+	}
+
+	select {
+	case update := <-updates:
+		if update.Price != 31000.0 {
+			t.Errorf("Expected polled price 31000, got %+v", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for polled price")
+	}
+
+	cancel()
+	for range updates {
+	}
+}
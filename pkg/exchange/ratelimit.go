@@ -0,0 +1,223 @@
+package exchange
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimits are the Binance API limits a BinanceClient keeps its requests
+// under. The exchange counts them in fixed windows: request weight per
+// minute, orders per 10 seconds and orders per UTC day.
+type RateLimits struct {
+	RequestWeightPerMinute int // Request weight per minute, 0 for no limit
+	OrdersPer10s           int // New orders per 10 seconds, 0 for no limit
+	OrdersPerDay           int // New orders per day, 0 for no limit
+}
+
+// defaultRateLimits are the spot API limits of the testnet and live exchange
+var defaultRateLimits = RateLimits{
+	RequestWeightPerMinute: 6000,
+	OrdersPer10s:           100,
+	OrdersPerDay:           200000,
+}
+
+// requestWeights holds the weight of the endpoints the client uses, called
+// with a symbol. Other endpoints count with a weight of 1.
+var requestWeights = map[string]int{
+	"GET /api/v3/ticker/price":      2,
+	"GET /api/v3/exchangeInfo":      20,
+	"GET /api/v3/account":           20,
+	"GET /api/v3/order":             4,
+	"GET /api/v3/openOrders":        6,
+	"POST /api/v3/order":            1,
+	"DELETE /api/v3/order":          1,
+	"POST /api/v3/userDataStream":   2,
+	"PUT /api/v3/userDataStream":    2,
+	"DELETE /api/v3/userDataStream": 2,
+}
+
+// Response headers reporting the usage the exchange has counted
+const (
+	headerUsedWeight   = "X-MBX-USED-WEIGHT-1M"
+	headerOrderCount10 = "X-MBX-ORDER-COUNT-10S"
+	headerOrderCountD  = "X-MBX-ORDER-COUNT-1D"
+)
+
+// SetRateLimits makes the client keep its requests under limits instead of the
+// exchange's default spot limits
+func (c *BinanceClient) SetRateLimits(limits RateLimits) {
+	c.limiter.mu.Lock()
+	defer c.limiter.mu.Unlock()
+
+	c.limiter.weight.limit = limits.RequestWeightPerMinute
+	c.limiter.orders.limit = limits.OrdersPer10s
+	c.limiter.dailyOrders.limit = limits.OrdersPerDay
+}
+
+// rateWindow counts usage in fixed windows of a given length
+type rateWindow struct {
+	interval time.Duration
+	limit    int       // 0 for no limit
+	start    time.Time // Start of the current window
+	used     int
+}
+
+// roll starts a new window if now is past the current one
+func (w *rateWindow) roll(now time.Time) {
+	if start := now.Truncate(w.interval); start.After(w.start) {
+		w.start = start
+		w.used = 0
+	}
+}
+
+// wait returns how long until cost fits into the window, 0 if it fits now.
+// A cost above the limit fits into an empty window.
+func (w *rateWindow) wait(now time.Time, cost int) time.Duration {
+	if w.limit <= 0 || cost == 0 || w.used+cost <= w.limit || w.used == 0 {
+		return 0
+	}
+	return w.start.Add(w.interval).Sub(now)
+}
+
+// sync raises the usage to what the exchange reported for the current window
+func (w *rateWindow) sync(header string) {
+	if used, err := strconv.Atoi(header); err == nil && used > w.used {
+		w.used = used
+	}
+}
+
+// rateLimiter throttles requests to stay under the exchange's rate limits and
+// holds all requests back after the exchange rejected one for exceeding them
+type rateLimiter struct {
+	mu          sync.Mutex
+	weight      rateWindow
+	orders      rateWindow
+	dailyOrders rateWindow
+	blocked     time.Time // No requests before this time
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// newRateLimiter returns a limiter that enforces limits
+func newRateLimiter(limits RateLimits, logger *slog.Logger) *rateLimiter {
+	return &rateLimiter{
+		weight:      rateWindow{interval: time.Minute, limit: limits.RequestWeightPerMinute},
+		orders:      rateWindow{interval: 10 * time.Second, limit: limits.OrdersPer10s},
+		dailyOrders: rateWindow{interval: 24 * time.Hour, limit: limits.OrdersPerDay},
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// reserve counts a request of the given weight placing the given number of
+// orders if it fits under the limits now. Otherwise it returns how long to
+// wait before trying again.
+func (l *rateLimiter) reserve(weight, orders int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.blocked) {
+		return l.blocked.Sub(now)
+	}
+	l.weight.roll(now)
+	l.orders.roll(now)
+	l.dailyOrders.roll(now)
+
+	delay := max(l.weight.wait(now, weight), l.orders.wait(now, orders), l.dailyOrders.wait(now, orders))
+	if delay > 0 {
+		return delay
+	}
+	l.weight.used += weight
+	l.orders.used += orders
+	l.dailyOrders.used += orders
+	return 0
+}
+
+// wait blocks until a request of the given weight placing the given number of
+// orders fits under the limits, or ctx is canceled
+func (l *rateLimiter) wait(ctx context.Context, request string, weight, orders int) error {
+	for {
+		delay := l.reserve(weight, orders)
+		if delay == 0 {
+			return nil
+		}
+		l.logger.Debug("Throttling exchange request", "request", request, "delay", delay)
+		if !sleepContext(ctx, delay) {
+			return ctx.Err()
+		}
+	}
+}
+
+// update records the usage reported in a response and holds requests back
+// after a 429 or 418 rejection, for as long as the exchange asks or else
+// until the current minute is over
+func (l *rateLimiter) update(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.weight.roll(now)
+	l.orders.roll(now)
+	l.dailyOrders.roll(now)
+	l.weight.sync(resp.Header.Get(headerUsedWeight))
+	l.orders.sync(resp.Header.Get(headerOrderCount10))
+	l.dailyOrders.sync(resp.Header.Get(headerOrderCountD))
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusTeapot {
+		return
+	}
+	until := l.weight.start.Add(l.weight.interval)
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		until = now.Add(time.Duration(seconds) * time.Second)
+	}
+	if until.After(l.blocked) {
+		l.blocked = until
+		l.logger.Warn("Exchange rate limit exceeded, holding requests back", "status", resp.StatusCode,
+			"until", until)
+	}
+}
+
+// limitingTransport passes requests on once the rate limiter admits them
+type limitingTransport struct {
+	next    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *limitingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request := req.Method + " " + req.URL.Path
+	weight, ok := requestWeights[request]
+	if !ok {
+		weight = 1
+	}
+	orders := 0
+	if request == "POST /api/v3/order" {
+		orders = 1
+	}
+	if err := t.limiter.wait(req.Context(), request, weight, orders); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		t.limiter.update(resp)
+	}
+	return resp, err
+}
+
+// limitRequests routes the client's requests through its rate limiter. The
+// HTTP client is copied so that a shared client is left untouched.
+func (c *BinanceClient) limitRequests() {
+	httpClient := *c.client.HTTPClient
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c.transport = &limitingTransport{next: transport, limiter: c.limiter}
+	httpClient.Transport = c.transport
+	c.client.HTTPClient = &httpClient
+}
@@ -0,0 +1,123 @@
+package exchange
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 5, 0, time.UTC)
+	limiter := newRateLimiter(RateLimits{RequestWeightPerMinute: 10, OrdersPer10s: 2, OrdersPerDay: 3}, slog.Default())
+
+	steps := []struct {
+		name      string
+		elapsed   time.Duration
+		weight    int
+		orders    int
+		wantDelay time.Duration
+	}{
+		{name: "Weight", weight: 4},
+		{name: "First order", weight: 1, orders: 1},
+		{name: "Second order", weight: 1, orders: 1},
+		{name: "Third order waits for the next 10s", weight: 1, orders: 1, wantDelay: 5 * time.Second},
+		{name: "Third order in the next 10s", elapsed: 5 * time.Second, weight: 1, orders: 1},
+		{name: "Weight waits for the next minute", elapsed: 5 * time.Second, weight: 4, wantDelay: 50 * time.Second},
+		{name: "Order waits for the next day", elapsed: time.Minute, weight: 1, orders: 1, wantDelay: 11*time.Hour + 58*time.Minute + 55*time.Second},
+		{name: "Weight above the limit in an empty minute", elapsed: time.Minute, weight: 20},
+	}
+
+	for _, step := range steps {
+		limiter.now = func() time.Time { return start.Add(step.elapsed) }
+		if delay := limiter.reserve(step.weight, step.orders); delay != step.wantDelay {
+			t.Errorf("%s: reserve() = %v, want %v", step.name, delay, step.wantDelay)
+		}
+	}
+}
+
+func TestRateLimiterResponses(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		status    int
+		headers   map[string]string
+		weight    int
+		orders    int
+		wantDelay time.Duration // Delay for a request of weight and orders after the response
+	}{
+		{
+			name:      "Used weight",
+			status:    http.StatusOK,
+			headers:   map[string]string{"X-MBX-USED-WEIGHT-1M": "5999"},
+			weight:    2,
+			wantDelay: 55 * time.Second,
+		},
+		{
+			name:      "Order count",
+			status:    http.StatusOK,
+			headers:   map[string]string{"X-MBX-USED-WEIGHT-1M": "2", "X-MBX-ORDER-COUNT-10S": "100"},
+			weight:    1,
+			orders:    1,
+			wantDelay: 5 * time.Second,
+		},
+		{
+			name:   "Requests without orders pass at the order limit",
+			status: http.StatusOK,
+			headers: map[string]string{"X-MBX-USED-WEIGHT-1M": "2", "X-MBX-ORDER-COUNT-10S": "100",
+				"X-MBX-ORDER-COUNT-1D": "100"},
+			weight: 2,
+		},
+		{
+			name:      "Rate limited",
+			status:    http.StatusTooManyRequests,
+			headers:   map[string]string{"Retry-After": "30"},
+			weight:    1,
+			wantDelay: 30 * time.Second,
+		},
+		{
+			name:      "Banned without Retry-After",
+			status:    http.StatusTeapot,
+			weight:    1,
+			wantDelay: 55 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				for name, value := range tt.headers {
+					w.Header().Set(name, value)
+				}
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					w.Write([]byte(`[{"symbol":"BTCUSDT","price":"30000.00"}]`))
+					return
+				}
+				w.Write([]byte(`{"code":-1003,"msg":"Too much request weight used."}`))
+			})
+			client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+			client.limiter.now = func() time.Time { return now }
+
+			client.GetSymbolPrice(context.Background(), "BTCUSDT")
+			if delay := client.limiter.reserve(tt.weight, tt.orders); delay != tt.wantDelay {
+				t.Errorf("reserve() = %v, want %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := newRateLimiter(RateLimits{RequestWeightPerMinute: 1}, slog.Default())
+	if err := limiter.wait(context.Background(), "GET /api/v3/order", 1, 0); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(ctx, "GET /api/v3/order", 1, 0); err == nil {
+		t.Error("Expected wait() to give up when the context is canceled")
+	}
+}
@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how a BinanceClient retries requests that failed with a
+// retryable error
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts per request including the first, 1 disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled for every further retry
+	MaxDelay    time.Duration // Upper bound for the delay
+}
+
+// defaultRetryPolicy is used by new clients
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// SetRetryPolicy makes the client retry failed requests according to policy
+// instead of the default of 4 attempts starting 250ms apart
+func (c *BinanceClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// retry calls op until it succeeds, fails with an error retryable rejects,
+// runs out of attempts or ctx is canceled, waiting a jittered, exponentially
+// growing delay between attempts. It returns op's last error, classified.
+func (c *BinanceClient) retry(ctx context.Context, request string, retryable func(error) bool, op func() error) error {
+	policy := c.retryPolicy
+	delay := policy.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		err = classify(err)
+		if attempt >= policy.MaxAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := jitter(delay)
+		c.logger.Warn("Retrying exchange request", "request", request, "attempt", attempt,
+			"delay", wait, "error", err)
+		if !sleepContext(ctx, wait) {
+			return err
+		}
+		delay = min(delay*2, policy.MaxDelay)
+	}
+}
+
+// jitter returns a random duration between half of d and d, so that clients
+// failing together do not retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
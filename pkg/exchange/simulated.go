@@ -0,0 +1,379 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+)
+
+// SimulatorConfig configures a SimulatedExchange
+type SimulatorConfig struct {
+	Symbol   types.SymbolInfo   // Traded symbol; BaseAsset and QuoteAsset are required
+	Balances map[string]float64 // Initial free balances by asset
+	MakerFee float64            // Fee rate for resting limit orders, e.g. 0.001 for 0.1%
+	TakerFee float64            // Fee rate for market orders and limit orders that cross the price
+}
+
+// SimulatedExchange is an in-memory exchange for a single symbol. Limit
+// orders rest until SetPrice moves the market through their price and then
+// fill in full at the limit price. Like on the exchange, buys pay their fees in
+// the base asset and sells in the quote asset.
+type SimulatedExchange struct {
+	mu     sync.Mutex
+	config SimulatorConfig
+	price  float64
+	now    time.Time
+	free   map[string]float64 // Balances available for new orders
+	locked map[string]float64 // Balances held by resting orders
+	open   map[string]*simulatedOrder
+	closed map[string]types.OrderInfo
+	nextID int64
+	fills  []types.ExecutionReport
+	fees   float64
+}
+
+// simulatedOrder is a resting limit order
+type simulatedOrder struct {
+	info types.OrderInfo
+	seq  int64 // Placement order, for deterministic matching
+}
+
+// NewSimulatedExchange creates a simulated exchange with the given balances
+func NewSimulatedExchange(config SimulatorConfig) (*SimulatedExchange, error) {
+	if config.Symbol.Symbol == "" || config.Symbol.BaseAsset == "" || config.Symbol.QuoteAsset == "" {
+		return nil, fmt.Errorf("symbol, base asset and quote asset are required")
+	}
+	if config.MakerFee < 0 || config.TakerFee < 0 {
+		return nil, fmt.Errorf("fee rates must not be negative")
+	}
+
+	e := &SimulatedExchange{
+		config: config,
+		free:   make(map[string]float64),
+		locked: make(map[string]float64),
+		open:   make(map[string]*simulatedOrder),
+		closed: make(map[string]types.OrderInfo),
+	}
+	for asset, balance := range config.Balances {
+		e.free[asset] = balance
+	}
+	return e, nil
+}
+
+// SetPrice moves the market to price at time t and fills every resting buy
+// order at or above it and every resting sell order at or below it. The
+// fills are returned in the order they happened.
+func (e *SimulatedExchange) SetPrice(t time.Time, price float64) []types.ExecutionReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.price = price
+	e.now = t
+
+	var crossed []*simulatedOrder
+	for _, order := range e.open {
+		if (order.info.Side == "BUY" && order.info.Price >= price) ||
+			(order.info.Side == "SELL" && order.info.Price <= price) {
+			crossed = append(crossed, order)
+		}
+	}
+	// Orders nearest the previous price fill first
+	sort.Slice(crossed, func(i, j int) bool {
+		a, b := crossed[i].info, crossed[j].info
+		if a.Price != b.Price {
+			if a.Side == "BUY" {
+				return a.Price > b.Price
+			}
+			return a.Price < b.Price
+		}
+		return crossed[i].seq < crossed[j].seq
+	})
+
+	fills := make([]types.ExecutionReport, 0, len(crossed))
+	for _, order := range crossed {
+		fills = append(fills, e.fill(order, order.info.Price, e.config.MakerFee))
+	}
+	return fills
+}
+
+// Fills returns every fill since the exchange was created
+func (e *SimulatedExchange) Fills() []types.ExecutionReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]types.ExecutionReport(nil), e.fills...)
+}
+
+// Fees returns the total fees paid, in the quote asset
+func (e *SimulatedExchange) Fees() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.fees
+}
+
+// Holdings returns the free plus locked balance of an asset
+func (e *SimulatedExchange) Holdings(asset string) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.free[asset] + e.locked[asset]
+}
+
+// GetSymbolPrice returns the current simulated price
+func (e *SimulatedExchange) GetSymbolPrice(ctx context.Context, symbol string) (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkSymbol(symbol); err != nil {
+		return 0, err
+	}
+	if e.price <= 0 {
+		return 0, fmt.Errorf("no price for symbol %s yet", symbol)
+	}
+	return e.price, nil
+}
+
+// GetSymbolInfo returns the trading rules of the simulated symbol
+func (e *SimulatedExchange) GetSymbolInfo(ctx context.Context, symbol string) (types.SymbolInfo, error) {
+	if err := e.checkSymbol(symbol); err != nil {
+		return types.SymbolInfo{}, err
+	}
+	return e.config.Symbol, nil
+}
+
+// PlaceOrder places a limit or market order. Market orders and limit orders
+// that cross the current price fill immediately at the current price. Like
+// the exchange it rejects a client order ID used by an open order.
+func (e *SimulatedExchange) PlaceOrder(ctx context.Context, order types.Order) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkSymbol(order.Symbol); err != nil {
+		return "", err
+	}
+	info := e.config.Symbol
+	for _, resting := range e.open {
+		if order.ClientOrderID != "" && resting.info.ClientOrderID == order.ClientOrderID {
+			return "", fmt.Errorf("%w: client order ID %s is in use", types.ErrDuplicateOrder, order.ClientOrderID)
+		}
+	}
+
+	quantity := grid.FloorToStep(order.Quantity, info.StepSize)
+	if quantity <= 0 || quantity < info.MinQty {
+		return "", fmt.Errorf("%w: quantity %v is below the minimum %v for %s", types.ErrOrderRejected, order.Quantity, info.MinQty, info.Symbol)
+	}
+
+	var price float64
+	switch order.Type {
+	case "LIMIT":
+		price = grid.RoundToTick(order.Price, info.TickSize)
+		if price <= 0 {
+			return "", fmt.Errorf("%w: price %v is below the tick size %v for %s", types.ErrOrderRejected, order.Price, info.TickSize, info.Symbol)
+		}
+	case "MARKET":
+		if e.price <= 0 {
+			return "", fmt.Errorf("no price for symbol %s yet", info.Symbol)
+		}
+		price = e.price
+	default:
+		return "", fmt.Errorf("unsupported order type %q", order.Type)
+	}
+	if notional := price * quantity; notional < info.MinNotional {
+		return "", fmt.Errorf("%w: order value %.8f is below the minimum notional %v for %s",
+			types.ErrOrderRejected, notional, info.MinNotional, info.Symbol)
+	}
+
+	// Lock the funds the order needs
+	var asset string
+	var amount float64
+	switch order.Side {
+	case "BUY":
+		asset, amount = info.QuoteAsset, price*quantity
+	case "SELL":
+		asset, amount = info.BaseAsset, quantity
+	default:
+		return "", fmt.Errorf("invalid order side %q", order.Side)
+	}
+	if e.free[asset] < amount {
+		return "", fmt.Errorf("%w of %s: need %.8f, have %.8f", types.ErrInsufficientBalance, asset, amount, e.free[asset])
+	}
+	e.free[asset] -= amount
+	e.locked[asset] += amount
+
+	e.nextID++
+	resting := &simulatedOrder{
+		info: types.OrderInfo{
+			OrderID:       strconv.FormatInt(e.nextID, 10),
+			ClientOrderID: order.ClientOrderID,
+			Symbol:        order.Symbol,
+			Side:          order.Side,
+			Price:         price,
+			Quantity:      quantity,
+			Status:        types.OrderStatusNew,
+		},
+		seq: e.nextID,
+	}
+	e.open[resting.info.OrderID] = resting
+
+	crosses := (order.Side == "BUY" && price >= e.price) || (order.Side == "SELL" && price <= e.price)
+	if order.Type == "MARKET" || (e.price > 0 && crosses) {
+		e.fill(resting, e.price, e.config.TakerFee)
+	}
+
+	return resting.info.OrderID, nil
+}
+
+// CancelOrder cancels a resting order and releases its funds
+func (e *SimulatedExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkSymbol(symbol); err != nil {
+		return err
+	}
+	order, ok := e.open[orderID]
+	if !ok {
+		return fmt.Errorf("%w %s", types.ErrUnknownOrder, orderID)
+	}
+
+	asset, amount := e.lockedFunds(order.info)
+	e.locked[asset] -= amount
+	e.free[asset] += amount
+
+	order.info.Status = types.OrderStatusCanceled
+	e.close(order)
+	return nil
+}
+
+// GetBalance returns the free balance of an asset
+func (e *SimulatedExchange) GetBalance(ctx context.Context, asset string) (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.free[asset], nil
+}
+
+// GetOrder returns the current state of an order
+func (e *SimulatedExchange) GetOrder(ctx context.Context, symbol, orderID string) (types.OrderInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkSymbol(symbol); err != nil {
+		return types.OrderInfo{}, err
+	}
+	if order, ok := e.open[orderID]; ok {
+		return order.info, nil
+	}
+	if info, ok := e.closed[orderID]; ok {
+		return info, nil
+	}
+	return types.OrderInfo{}, fmt.Errorf("%w %s", types.ErrUnknownOrder, orderID)
+}
+
+// GetOpenOrders lists the resting orders, oldest first
+func (e *SimulatedExchange) GetOpenOrders(ctx context.Context, symbol string) ([]types.OrderInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkSymbol(symbol); err != nil {
+		return nil, err
+	}
+	orders := make([]*simulatedOrder, 0, len(e.open))
+	for _, order := range e.open {
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].seq < orders[j].seq })
+
+	infos := make([]types.OrderInfo, len(orders))
+	for i, order := range orders {
+		infos[i] = order.info
+	}
+	return infos, nil
+}
+
+// fill executes a resting order in full at price and settles the balances
+func (e *SimulatedExchange) fill(order *simulatedOrder, price, feeRate float64) types.ExecutionReport {
+	info := e.config.Symbol
+	quantity := order.info.Quantity
+	value := price * quantity
+
+	// Like the exchange, take the commission from the asset received
+	asset, amount := e.lockedFunds(order.info)
+	e.locked[asset] -= amount
+	var fee float64
+	var feeAsset string
+	if order.info.Side == "BUY" {
+		// A buy may execute below its limit, refund the difference
+		e.free[info.QuoteAsset] += amount - value
+		fee, feeAsset = quantity*feeRate, info.BaseAsset
+		e.free[info.BaseAsset] += quantity - fee
+		e.fees += fee * price
+	} else {
+		fee, feeAsset = value*feeRate, info.QuoteAsset
+		e.free[info.QuoteAsset] += value - fee
+		e.fees += fee
+	}
+
+	order.info.ExecutedQuantity = quantity
+	order.info.AveragePrice = price
+	order.info.Status = types.OrderStatusFilled
+	e.close(order)
+
+	report := types.ExecutionReport{
+		Symbol:             order.info.Symbol,
+		OrderID:            order.info.OrderID,
+		ClientOrderID:      order.info.ClientOrderID,
+		Side:               order.info.Side,
+		Status:             types.OrderStatusFilled,
+		Price:              order.info.Price,
+		Quantity:           quantity,
+		LastQuantity:       quantity,
+		LastPrice:          price,
+		CumulativeQuantity: quantity,
+		Commission:         fee,
+		CommissionAsset:    feeAsset,
+		Time:               e.now,
+	}
+	e.fills = append(e.fills, report)
+	return report
+}
+
+// fillsFrom returns the fills after the first n
+func (e *SimulatedExchange) fillsFrom(n int) []types.ExecutionReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if n >= len(e.fills) {
+		return nil
+	}
+	return append([]types.ExecutionReport(nil), e.fills[n:]...)
+}
+
+// lockedFunds returns the asset and amount a resting order holds
+func (e *SimulatedExchange) lockedFunds(order types.OrderInfo) (string, float64) {
+	if order.Side == "BUY" {
+		return e.config.Symbol.QuoteAsset, order.Price * order.Quantity
+	}
+	return e.config.Symbol.BaseAsset, order.Quantity
+}
+
+// close moves an order from the open to the closed set
+func (e *SimulatedExchange) close(order *simulatedOrder) {
+	delete(e.open, order.info.OrderID)
+	e.closed[order.info.OrderID] = order.info
+}
+
+// checkSymbol rejects requests for symbols other than the simulated one
+func (e *SimulatedExchange) checkSymbol(symbol string) error {
+	if symbol != e.config.Symbol.Symbol {
+		return fmt.Errorf("unknown symbol %s", symbol)
+	}
+	return nil
+}
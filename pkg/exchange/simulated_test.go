@@ -0,0 +1,152 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+)
+
+func newTestSimulator(t *testing.T) *SimulatedExchange {
+	t.Helper()
+
+	sim, err := NewSimulatedExchange(SimulatorConfig{
+		Symbol: types.SymbolInfo{
+			Symbol:     "BTCUSDT",
+			BaseAsset:  "BTC",
+			QuoteAsset: "USDT",
+			TickSize:   0.01,
+			StepSize:   0.0001,
+		},
+		Balances: map[string]float64{"USDT": 10000, "BTC": 1},
+		MakerFee: 0.001,
+		TakerFee: 0.002,
+	})
+	if err != nil {
+		t.Fatalf("NewSimulatedExchange() error = %v", err)
+	}
+	sim.SetPrice(time.Unix(0, 0), 30000)
+	return sim
+}
+
+func assertBalance(t *testing.T, sim *SimulatedExchange, asset string, want float64) {
+	t.Helper()
+
+	got, err := sim.GetBalance(context.Background(), asset)
+	if err != nil {
+		t.Fatalf("GetBalance(%s) error = %v", asset, err)
+	}
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s balance = %v, want %v", asset, got, want)
+	}
+}
+
+func TestSimulatedExchangeLimitOrders(t *testing.T) {
+	ctx := context.Background()
+	sim := newTestSimulator(t)
+
+	buyID, err := sim.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "LIMIT", Price: 29000, Quantity: 0.1})
+	if err != nil {
+		t.Fatalf("PlaceOrder(BUY) error = %v", err)
+	}
+	sellID, err := sim.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "SELL", Type: "LIMIT", Price: 31000, Quantity: 0.5})
+	if err != nil {
+		t.Fatalf("PlaceOrder(SELL) error = %v", err)
+	}
+
+	// Placing orders locks their funds
+	assertBalance(t, sim, "USDT", 10000-2900)
+	assertBalance(t, sim, "BTC", 0.5)
+
+	// A move that crosses neither order fills nothing
+	if fills := sim.SetPrice(time.Unix(60, 0), 29500); len(fills) != 0 {
+		t.Fatalf("Expected no fills at 29500, got %+v", fills)
+	}
+
+	fills := sim.SetPrice(time.Unix(120, 0), 28900)
+	if len(fills) != 1 || fills[0].OrderID != buyID || fills[0].LastPrice != 29000 {
+		t.Fatalf("Expected the buy to fill at its limit, got %+v", fills)
+	}
+	// The buy's commission comes out of the BTC it receives
+	if fee := fills[0].Commission; math.Abs(fee-0.0001) > 1e-9 || fills[0].CommissionAsset != "BTC" {
+		t.Errorf("Commission = %v %s, want 0.0001 BTC", fee, fills[0].CommissionAsset)
+	}
+	assertBalance(t, sim, "USDT", 10000-2900)
+	assertBalance(t, sim, "BTC", 0.5999)
+
+	info, err := sim.GetOrder(ctx, "BTCUSDT", buyID)
+	if err != nil || info.Status != types.OrderStatusFilled || info.ExecutedQuantity != 0.1 {
+		t.Errorf("GetOrder() = %+v, %v; want filled", info, err)
+	}
+
+	// Canceling releases the locked base asset
+	if err := sim.CancelOrder(ctx, "BTCUSDT", sellID); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+	assertBalance(t, sim, "BTC", 1.0999)
+	if open, _ := sim.GetOpenOrders(ctx, "BTCUSDT"); len(open) != 0 {
+		t.Errorf("Expected no open orders, got %+v", open)
+	}
+	if got := sim.Holdings("USDT") + sim.Holdings("BTC")*28900; math.Abs(got-(10000-2900+1.0999*28900)) > 1e-6 {
+		t.Errorf("Unexpected holdings value %v", got)
+	}
+}
+
+func TestSimulatedExchangeMarketAndCrossingOrders(t *testing.T) {
+	ctx := context.Background()
+	sim := newTestSimulator(t)
+
+	if _, err := sim.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET", Quantity: 0.1}); err != nil {
+		t.Fatalf("PlaceOrder(MARKET) error = %v", err)
+	}
+	assertBalance(t, sim, "USDT", 10000-3000)
+	assertBalance(t, sim, "BTC", 1.0998)
+
+	// A buy limit above the market executes at the market price
+	if _, err := sim.PlaceOrder(ctx, types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "LIMIT", Price: 31000, Quantity: 0.1}); err != nil {
+		t.Fatalf("PlaceOrder(crossing LIMIT) error = %v", err)
+	}
+	assertBalance(t, sim, "USDT", 10000-6000)
+	if got := len(sim.Fills()); got != 2 {
+		t.Errorf("Expected 2 fills, got %d", got)
+	}
+	if got := sim.Fees(); math.Abs(got-12) > 1e-9 {
+		t.Errorf("Fees() = %v, want 12", got)
+	}
+}
+
+func TestSimulatedExchangeRejectsOrders(t *testing.T) {
+	ctx := context.Background()
+	sim := newTestSimulator(t)
+
+	tests := []struct {
+		name  string
+		order types.Order
+	}{
+		{name: "Insufficient quote", order: types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "LIMIT", Price: 29000, Quantity: 1}},
+		{name: "Insufficient base", order: types.Order{Symbol: "BTCUSDT", Side: "SELL", Type: "LIMIT", Price: 31000, Quantity: 2}},
+		{name: "Below step size", order: types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "LIMIT", Price: 29000, Quantity: 0.00001}},
+		{name: "Unknown symbol", order: types.Order{Symbol: "ETHUSDT", Side: "BUY", Type: "LIMIT", Price: 1500, Quantity: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := sim.PlaceOrder(ctx, tt.order); err == nil {
+				t.Error("Expected PlaceOrder to fail")
+			}
+		})
+	}
+	assertBalance(t, sim, "USDT", 10000)
+	assertBalance(t, sim, "BTC", 1)
+
+	order := types.Order{Symbol: "BTCUSDT", Side: "BUY", Type: "LIMIT", Price: 29000, Quantity: 0.1, ClientOrderID: "grid_0_B_1"}
+	if _, err := sim.PlaceOrder(ctx, order); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if _, err := sim.PlaceOrder(ctx, order); !errors.Is(err, types.ErrDuplicateOrder) {
+		t.Errorf("PlaceOrder() with a client order ID in use error = %v, want %v", err, types.ErrDuplicateOrder)
+	}
+}
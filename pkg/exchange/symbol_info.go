@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"spot_grid_bot/pkg/grid"
+	"spot_grid_bot/pkg/types"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+// GetSymbolInfo returns the trading rules for a symbol. Results are cached
+// for the lifetime of the client.
+func (c *BinanceClient) GetSymbolInfo(ctx context.Context, symbol string) (types.SymbolInfo, error) {
+	c.mu.Lock()
+	info, ok := c.symbols[symbol]
+	c.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	var exchangeInfo *binance.ExchangeInfo
+	err := c.retry(ctx, "get exchange info", types.IsRetryable, func() (err error) {
+		exchangeInfo, err = c.client.NewExchangeInfoService().Symbol(symbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return types.SymbolInfo{}, fmt.Errorf("failed to get exchange info: %w", err)
+	}
+
+	for i := range exchangeInfo.Symbols {
+		if exchangeInfo.Symbols[i].Symbol != symbol {
+			continue
+		}
+		info, err := toSymbolInfo(&exchangeInfo.Symbols[i])
+		if err != nil {
+			return types.SymbolInfo{}, err
+		}
+
+		c.mu.Lock()
+		c.symbols[symbol] = info
+		c.mu.Unlock()
+		return info, nil
+	}
+
+	return types.SymbolInfo{}, fmt.Errorf("symbol %s not found in exchange info", symbol)
+}
+
+// toSymbolInfo extracts the price, lot size and notional filters of a symbol
+func toSymbolInfo(symbol *binance.Symbol) (types.SymbolInfo, error) {
+	info := types.SymbolInfo{
+		Symbol:     symbol.Symbol,
+		BaseAsset:  symbol.BaseAsset,
+		QuoteAsset: symbol.QuoteAsset,
+	}
+
+	var err error
+	if f := symbol.PriceFilter(); f != nil {
+		if info.TickSize, err = parseDecimal(f.TickSize); err != nil {
+			return types.SymbolInfo{}, fmt.Errorf("failed to parse tick size: %w", err)
+		}
+	}
+	if f := symbol.LotSizeFilter(); f != nil {
+		if info.StepSize, err = parseDecimal(f.StepSize); err != nil {
+			return types.SymbolInfo{}, fmt.Errorf("failed to parse step size: %w", err)
+		}
+		if info.MinQty, err = parseDecimal(f.MinQuantity); err != nil {
+			return types.SymbolInfo{}, fmt.Errorf("failed to parse minimum quantity: %w", err)
+		}
+	}
+	if f := symbol.NotionalFilter(); f != nil {
+		if info.MinNotional, err = parseDecimal(f.MinNotional); err != nil {
+			return types.SymbolInfo{}, fmt.Errorf("failed to parse minimum notional: %w", err)
+		}
+	}
+
+	// Some symbols still carry the legacy MIN_NOTIONAL filter
+	for _, filter := range symbol.Filters {
+		if filter["filterType"] != string(binance.SymbolFilterTypeMinNotional) {
+			continue
+		}
+		if v, ok := filter["minNotional"].(string); ok {
+			if info.MinNotional, err = parseDecimal(v); err != nil {
+				return types.SymbolInfo{}, fmt.Errorf("failed to parse minimum notional: %w", err)
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// applyFilters rounds an order to the symbol's tick and step size and checks
+// the minimum quantity and notional. A MARKET order's notional is checked at
+// its Price, the price it is expected to fill at. It returns the price and
+// quantity formatted for submission, the price empty for a MARKET order.
+func applyFilters(order types.Order, info types.SymbolInfo) (price, quantity string, err error) {
+	qty := grid.FloorToStep(order.Quantity, info.StepSize)
+	if qty <= 0 || qty < info.MinQty {
+		return "", "", fmt.Errorf("quantity %v is below the minimum %v for %s",
+			order.Quantity, info.MinQty, info.Symbol)
+	}
+	quantity = strconv.FormatFloat(qty, 'f', grid.Decimals(info.StepSize), 64)
+
+	if order.Type != "LIMIT" {
+		if err := checkNotional(order.Price, qty, info); err != nil {
+			return "", "", err
+		}
+		return "", quantity, nil
+	}
+
+	p := grid.RoundToTick(order.Price, info.TickSize)
+	if p <= 0 {
+		return "", "", fmt.Errorf("price %v is below the tick size %v for %s", order.Price, info.TickSize, info.Symbol)
+	}
+	if err := checkNotional(p, qty, info); err != nil {
+		return "", "", err
+	}
+	price = strconv.FormatFloat(p, 'f', grid.Decimals(info.TickSize), 64)
+
+	return price, quantity, nil
+}
+
+// checkNotional checks that an order of quantity at price is worth at least
+// the symbol's minimum notional
+func checkNotional(price, quantity float64, info types.SymbolInfo) error {
+	if notional := price * quantity; notional < info.MinNotional {
+		return fmt.Errorf("order value %.8f is below the minimum notional %v for %s",
+			notional, info.MinNotional, info.Symbol)
+	}
+	return nil
+}
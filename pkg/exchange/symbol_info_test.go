@@ -0,0 +1,111 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"spot_grid_bot/pkg/types"
+)
+
+const testExchangeInfo = `{"symbols":[{"symbol":"BTCUSDT","baseAsset":"BTC","quoteAsset":"USDT","filters":[
+	{"filterType":"PRICE_FILTER","minPrice":"0.01000000","maxPrice":"1000000.00000000","tickSize":"0.01000000"},
+	{"filterType":"LOT_SIZE","minQty":"0.00001000","maxQty":"9000.00000000","stepSize":"0.00001000"},
+	{"filterType":"NOTIONAL","minNotional":"5.00000000","applyMinToMarket":true,"maxNotional":"9000000.00000000","applyMaxToMarket":false,"avgPriceMins":5}
+]}]}`
+
+func TestGetSymbolInfo(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/exchangeInfo" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		w.Write([]byte(testExchangeInfo))
+	})
+
+	want := types.SymbolInfo{
+		Symbol:      "BTCUSDT",
+		BaseAsset:   "BTC",
+		QuoteAsset:  "USDT",
+		TickSize:    0.01,
+		StepSize:    0.00001,
+		MinQty:      0.00001,
+		MinNotional: 5,
+	}
+
+	for i := 0; i < 2; i++ {
+		info, err := client.GetSymbolInfo(context.Background(), "BTCUSDT")
+		if err != nil {
+			t.Fatalf("GetSymbolInfo() error = %v", err)
+		}
+		if info != want {
+			t.Errorf("GetSymbolInfo() = %+v, want %+v", info, want)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected exchange info to be fetched once, got %d requests", requests)
+	}
+
+	if _, err := client.GetSymbolInfo(context.Background(), "ETHUSDT"); err == nil {
+		t.Error("Expected error for symbol missing from exchange info")
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	info := types.SymbolInfo{
+		Symbol:      "BTCUSDT",
+		TickSize:    0.01,
+		StepSize:    0.00001,
+		MinQty:      0.00001,
+		MinNotional: 5,
+	}
+
+	tests := []struct {
+		name         string
+		order        types.Order
+		wantPrice    string
+		wantQuantity string
+		wantErr      bool
+	}{
+		{
+			name:         "Limit order is rounded",
+			order:        types.Order{Type: "LIMIT", Price: 27500.123456, Quantity: 0.0123456789},
+			wantPrice:    "27500.12",
+			wantQuantity: "0.01234",
+		},
+		{
+			name:         "Market order has no price",
+			order:        types.Order{Type: "MARKET", Price: 27500, Quantity: 0.001},
+			wantQuantity: "0.00100",
+		},
+		{
+			name:    "Market order below minimum notional",
+			order:   types.Order{Type: "MARKET", Price: 27500, Quantity: 0.0001},
+			wantErr: true,
+		},
+		{
+			name:    "Quantity below step size",
+			order:   types.Order{Type: "LIMIT", Price: 27500, Quantity: 0.000001},
+			wantErr: true,
+		},
+		{
+			name:    "Below minimum notional",
+			order:   types.Order{Type: "LIMIT", Price: 27500, Quantity: 0.0001},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, quantity, err := applyFilters(tt.order, info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if price != tt.wantPrice || quantity != tt.wantQuantity {
+				t.Errorf("applyFilters() = %q, %q; want %q, %q", price, quantity, tt.wantPrice, tt.wantQuantity)
+			}
+		})
+	}
+}
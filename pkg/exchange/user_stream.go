@@ -0,0 +1,197 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+// listenKeyKeepaliveInterval is how often the listen key is extended.
+// Binance expires listen keys after 60 minutes without a keep-alive.
+const listenKeyKeepaliveInterval = 30 * time.Minute
+
+// wsUserDataServe opens the user data WebSocket; replaced in tests
+var wsUserDataServe = binance.WsUserDataServe
+
+// SubscribeExecutionReports streams execution reports for the account until
+// ctx is canceled. The stream reconnects with backoff whenever the WebSocket
+// drops; updates sent while disconnected are not replayed. The returned
+// channel is closed when ctx is canceled.
+func (c *BinanceClient) SubscribeExecutionReports(ctx context.Context) (<-chan types.ExecutionReport, error) {
+	listenKey, err := c.client.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listen key: %w", err)
+	}
+
+	reports := make(chan types.ExecutionReport, 64)
+	go c.serveUserData(ctx, listenKey, reports)
+
+	return reports, nil
+}
+
+// ExecutionReportsConnectedSince returns when the user data stream last
+// connected, or the zero time while it is down
+func (c *BinanceClient) ExecutionReportsConnectedSince() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.userStream
+}
+
+// setUserStreamConnected records whether the user data stream is connected
+func (c *BinanceClient) setUserStreamConnected(connected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.userStream = time.Time{}
+	if connected {
+		c.userStream = time.Now()
+	}
+}
+
+// serveUserData keeps the user data stream connected until ctx is canceled
+func (c *BinanceClient) serveUserData(ctx context.Context, listenKey string, reports chan<- types.ExecutionReport) {
+	defer close(reports)
+	defer c.setUserStreamConnected(false)
+	defer func() {
+		// ctx is already canceled here, so close the listen key on a fresh one
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := c.client.NewCloseUserStreamService().ListenKey(listenKey).Do(closeCtx); err != nil {
+			c.logger.Warn("Failed to close listen key", "error", err)
+		}
+	}()
+
+	handler := func(event *binance.WsUserDataEvent) {
+		if event.Event != binance.UserDataEventTypeExecutionReport {
+			return
+		}
+		report, err := toExecutionReport(&event.OrderUpdate)
+		if err != nil {
+			c.logger.Warn("Failed to parse execution report", "symbol", event.OrderUpdate.Symbol,
+				"orderID", event.OrderUpdate.Id, "error", err)
+			return
+		}
+		select {
+		case reports <- report:
+		case <-ctx.Done():
+		}
+	}
+
+	keepalive := time.NewTicker(listenKeyKeepaliveInterval)
+	defer keepalive.Stop()
+
+	delay := minReconnectDelay
+	for {
+		errC := make(chan error, 1)
+		errHandler := func(err error) {
+			select {
+			case errC <- err:
+			default:
+			}
+		}
+
+		doneC, stopC, err := wsUserDataServe(listenKey, handler, errHandler)
+		if err != nil {
+			c.logger.Warn("Failed to connect user data stream", "error", err)
+		} else {
+			delay = minReconnectDelay
+			c.setUserStreamConnected(true)
+			reconnect := c.watchUserData(ctx, &listenKey, keepalive, doneC, stopC, errC)
+			c.setUserStreamConnected(false)
+			if !reconnect {
+				return
+			}
+		}
+
+		if !sleepContext(ctx, delay) {
+			return
+		}
+		delay = nextBackoff(delay)
+
+		// The listen key may have expired while disconnected
+		if err := c.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx); err != nil {
+			if key, err := c.client.NewStartUserStreamService().Do(ctx); err == nil {
+				listenKey = key
+			} else {
+				c.logger.Warn("Failed to renew listen key", "error", err)
+			}
+		}
+	}
+}
+
+// watchUserData keeps a connected stream alive until it drops or ctx is
+// canceled. It returns false if ctx was canceled.
+func (c *BinanceClient) watchUserData(ctx context.Context, listenKey *string, keepalive *time.Ticker,
+	doneC, stopC chan struct{}, errC <-chan error) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			close(stopC)
+			<-doneC
+			return false
+		case <-keepalive.C:
+			if err := c.client.NewKeepaliveUserStreamService().ListenKey(*listenKey).Do(ctx); err != nil {
+				c.logger.Warn("Failed to keep listen key alive, reconnecting", "error", err)
+				close(stopC)
+				<-doneC
+				return true
+			}
+		case <-doneC:
+			select {
+			case err := <-errC:
+				c.logger.Warn("User data stream disconnected", "error", err)
+			default:
+				c.logger.Warn("User data stream disconnected")
+			}
+			return true
+		}
+	}
+}
+
+// toExecutionReport converts a Binance order update into an ExecutionReport
+func toExecutionReport(update *binance.WsOrderUpdate) (types.ExecutionReport, error) {
+	report := types.ExecutionReport{
+		Symbol:          update.Symbol,
+		OrderID:         strconv.FormatInt(update.Id, 10),
+		ClientOrderID:   update.ClientOrderId,
+		Side:            update.Side,
+		Status:          toOrderStatus(update.Status),
+		CommissionAsset: update.FeeAsset,
+		RejectReason:    update.RejectReason,
+		Time:            time.UnixMilli(update.TransactionTime),
+	}
+
+	var err error
+	if report.Price, err = parseDecimal(update.Price); err != nil {
+		return types.ExecutionReport{}, fmt.Errorf("failed to parse price: %w", err)
+	}
+	if report.Quantity, err = parseDecimal(update.Volume); err != nil {
+		return types.ExecutionReport{}, fmt.Errorf("failed to parse quantity: %w", err)
+	}
+	if report.LastQuantity, err = parseDecimal(update.LatestVolume); err != nil {
+		return types.ExecutionReport{}, fmt.Errorf("failed to parse last quantity: %w", err)
+	}
+	if report.LastPrice, err = parseDecimal(update.LatestPrice); err != nil {
+		return types.ExecutionReport{}, fmt.Errorf("failed to parse last price: %w", err)
+	}
+	if report.CumulativeQuantity, err = parseDecimal(update.FilledVolume); err != nil {
+		return types.ExecutionReport{}, fmt.Errorf("failed to parse cumulative quantity: %w", err)
+	}
+	if report.Commission, err = parseDecimal(update.FeeCost); err != nil {
+		return types.ExecutionReport{}, fmt.Errorf("failed to parse commission: %w", err)
+	}
+
+	return report, nil
+}
+
+// parseDecimal parses a decimal string, treating an empty string as zero
+func parseDecimal(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
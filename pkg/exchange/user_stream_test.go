@@ -0,0 +1,172 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/types"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+func TestToExecutionReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		update  binance.WsOrderUpdate
+		want    types.ExecutionReport
+		wantErr bool
+	}{
+		{
+			name: "Filled buy",
+			update: binance.WsOrderUpdate{
+				Symbol:          "BTCUSDT",
+				ClientOrderId:   "grid_1",
+				Side:            "BUY",
+				Status:          "FILLED",
+				Id:              42,
+				Price:           "27500.00",
+				Volume:          "0.00100000",
+				LatestVolume:    "0.00040000",
+				LatestPrice:     "27500.00",
+				FilledVolume:    "0.00100000",
+				FeeCost:         "0.00000040",
+				FeeAsset:        "BTC",
+				RejectReason:    "NONE",
+				TransactionTime: 1700000000000,
+			},
+			want: types.ExecutionReport{
+				Symbol:             "BTCUSDT",
+				OrderID:            "42",
+				ClientOrderID:      "grid_1",
+				Side:               "BUY",
+				Status:             types.OrderStatusFilled,
+				Price:              27500,
+				Quantity:           0.001,
+				LastQuantity:       0.0004,
+				LastPrice:          27500,
+				CumulativeQuantity: 0.001,
+				Commission:         0.0000004,
+				CommissionAsset:    "BTC",
+				RejectReason:       "NONE",
+				Time:               time.UnixMilli(1700000000000),
+			},
+		},
+		{
+			name: "New order without fee asset",
+			update: binance.WsOrderUpdate{
+				Symbol: "BTCUSDT",
+				Side:   "SELL",
+				Status: "NEW",
+				Id:     7,
+				Price:  "32500.00",
+				Volume: "0.00100000",
+			},
+			want: types.ExecutionReport{
+				Symbol:   "BTCUSDT",
+				OrderID:  "7",
+				Side:     "SELL",
+				Status:   types.OrderStatusNew,
+				Price:    32500,
+				Quantity: 0.001,
+				Time:     time.UnixMilli(0),
+			},
+		},
+		{
+			name: "Expired in match is reported as expired",
+			update: binance.WsOrderUpdate{
+				Status: "EXPIRED_IN_MATCH",
+				Id:     8,
+			},
+			want: types.ExecutionReport{
+				OrderID: "8",
+				Status:  types.OrderStatusExpired,
+				Time:    time.UnixMilli(0),
+			},
+		},
+		{
+			name: "Invalid price",
+			update: binance.WsOrderUpdate{
+				Price: "not-a-number",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toExecutionReport(&tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toExecutionReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("toExecutionReport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscribeExecutionReports(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/userDataStream" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"listenKey":"test-listen-key"}`))
+	})
+
+	// Serve a single execution report, then drop the first connection
+	connections := 0
+	original := wsUserDataServe
+	defer func() { wsUserDataServe = original }()
+	wsUserDataServe = func(listenKey string, handler binance.WsUserDataHandler, errHandler binance.ErrHandler) (chan struct{}, chan struct{}, error) {
+		if listenKey != "test-listen-key" {
+			t.Errorf("Unexpected listen key %q", listenKey)
+		}
+		connections++
+		first := connections == 1
+		doneC, stopC := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(doneC)
+			if first {
+				handler(&binance.WsUserDataEvent{
+					Event: binance.UserDataEventTypeExecutionReport,
+					OrderUpdate: binance.WsOrderUpdate{
+						Symbol: "BTCUSDT",
+						Side:   "BUY",
+						Status: "FILLED",
+						Id:     42,
+						Price:  "27500.00",
+					},
+				})
+				errHandler(context.DeadlineExceeded)
+				return
+			}
+			<-stopC
+		}()
+		return doneC, stopC, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reports, err := client.SubscribeExecutionReports(ctx)
+	if err != nil {
+		t.Fatalf("SubscribeExecutionReports() error = %v", err)
+	}
+
+	select {
+	case report := <-reports:
+		if report.OrderID != "42" || report.Status != types.OrderStatusFilled {
+			t.Errorf("Unexpected report %+v", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for execution report")
+	}
+
+	cancel()
+	for range reports {
+	}
+	if since := client.ExecutionReportsConnectedSince(); !since.IsZero() {
+		t.Errorf("ExecutionReportsConnectedSince() after the stream closed = %v, want zero", since)
+	}
+}
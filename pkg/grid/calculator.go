@@ -2,8 +2,52 @@ package grid
 
 import (
 	"fmt"
+	"math"
 )
 
+// Spacing determines how grid levels are distributed between the bounds
+type Spacing string
+
+const (
+	// SpacingArithmetic places levels a constant price difference apart
+	SpacingArithmetic Spacing = "arithmetic"
+	// SpacingGeometric places levels a constant ratio apart, so every grid
+	// yields the same profit in percentage terms
+	SpacingGeometric Spacing = "geometric"
+	// SpacingExplicit uses a fixed list of price levels
+	SpacingExplicit Spacing = "explicit"
+	// SpacingWeighted places levels densely around a center price
+	SpacingWeighted Spacing = "weighted"
+)
+
+// ParseSpacing converts a spacing name into a Spacing
+func ParseSpacing(name string) (Spacing, error) {
+	switch spacing := Spacing(name); spacing {
+	case SpacingArithmetic, SpacingGeometric, SpacingExplicit, SpacingWeighted:
+		return spacing, nil
+	}
+	return "", fmt.Errorf("unknown grid spacing %q, expected %q, %q, %q or %q",
+		name, SpacingArithmetic, SpacingGeometric, SpacingExplicit, SpacingWeighted)
+}
+
+// CalculateGridLevelsWithSpacing calculates the price levels for a grid
+// trading strategy using arithmetic or geometric spacing. An empty spacing
+// defaults to arithmetic. Other spacing modes need more parameters and are
+// available through their LevelGenerator.
+func CalculateGridLevelsWithSpacing(lowerPrice, upperPrice float64, gridNum int, spacing Spacing) ([]float64, error) {
+	if err := ValidateGridParams(lowerPrice, upperPrice, gridNum); err != nil {
+		return nil, err
+	}
+
+	switch spacing {
+	case "", SpacingArithmetic:
+		return CalculateGridLevels(lowerPrice, upperPrice, gridNum), nil
+	case SpacingGeometric:
+		return CalculateGeometricGridLevels(lowerPrice, upperPrice, gridNum), nil
+	}
+	return nil, fmt.Errorf("grid spacing %q cannot be calculated from bounds alone", spacing)
+}
+
 // CalculateGridLevels calculates the price levels for a grid trading strategy
 // lowerPrice: the lowest price in the grid
 // upperPrice: the highest price in the grid
@@ -25,6 +69,30 @@ func CalculateGridLevels(lowerPrice, upperPrice float64, gridNum int) []float64
 	return levels
 }
 
+// CalculateGeometricGridLevels calculates price levels that are a constant
+// ratio apart
+// lowerPrice: the lowest price in the grid
+// upperPrice: the highest price in the grid
+// gridNum: number of grid levels (must be >= 2)
+func CalculateGeometricGridLevels(lowerPrice, upperPrice float64, gridNum int) []float64 {
+	if err := ValidateGridParams(lowerPrice, upperPrice, gridNum); err != nil {
+		return nil
+	}
+
+	levels := make([]float64, gridNum)
+	// Calculate the price ratio between each grid level
+	ratio := math.Pow(upperPrice/lowerPrice, 1/float64(gridNum-1))
+
+	// Generate grid levels
+	for i := 0; i < gridNum; i++ {
+		levels[i] = lowerPrice * math.Pow(ratio, float64(i))
+	}
+	// Pin the top level to avoid floating point drift
+	levels[gridNum-1] = upperPrice
+
+	return levels
+}
+
 // ValidateGridParams validates the input parameters for grid calculation
 func ValidateGridParams(lowerPrice, upperPrice float64, gridNum int) error {
 	if lowerPrice <= 0 || upperPrice <= 0 {
@@ -1,6 +1,7 @@
 package grid
 
 import (
+	"math"
 	"testing"
 )
 
@@ -93,3 +94,116 @@ func TestValidateGridParams(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateGridLevelsWithSpacing(t *testing.T) {
+	tests := []struct {
+		name           string
+		lowerPrice     float64
+		upperPrice     float64
+		gridNum        int
+		spacing        Spacing
+		expectedLevels []float64
+		wantErr        bool
+	}{
+		{
+			name:           "Default spacing is arithmetic",
+			lowerPrice:     100.0,
+			upperPrice:     200.0,
+			gridNum:        5,
+			spacing:        "",
+			expectedLevels: []float64{100.0, 125.0, 150.0, 175.0, 200.0},
+		},
+		{
+			name:           "Arithmetic spacing",
+			lowerPrice:     1000.0,
+			upperPrice:     1300.0,
+			gridNum:        3,
+			spacing:        SpacingArithmetic,
+			expectedLevels: []float64{1000.0, 1150.0, 1300.0},
+		},
+		{
+			name:           "Geometric doubling",
+			lowerPrice:     100.0,
+			upperPrice:     800.0,
+			gridNum:        4,
+			spacing:        SpacingGeometric,
+			expectedLevels: []float64{100.0, 200.0, 400.0, 800.0},
+		},
+		{
+			name:           "Geometric ten percent steps",
+			lowerPrice:     1000.0,
+			upperPrice:     1331.0,
+			gridNum:        4,
+			spacing:        SpacingGeometric,
+			expectedLevels: []float64{1000.0, 1100.0, 1210.0, 1331.0},
+		},
+		{
+			name:       "Unknown spacing",
+			lowerPrice: 100.0,
+			upperPrice: 200.0,
+			gridNum:    5,
+			spacing:    "fibonacci",
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid parameters",
+			lowerPrice: 200.0,
+			upperPrice: 100.0,
+			gridNum:    5,
+			spacing:    SpacingGeometric,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels, err := CalculateGridLevelsWithSpacing(tt.lowerPrice, tt.upperPrice, tt.gridNum, tt.spacing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateGridLevelsWithSpacing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(levels) != len(tt.expectedLevels) {
+				t.Errorf("Expected %d levels, got %d", len(tt.expectedLevels), len(levels))
+				return
+			}
+
+			for i := range levels {
+				if math.Abs(levels[i]-tt.expectedLevels[i]) > 1e-9 {
+					t.Errorf("Level %d: expected %.2f, got %.2f", i, tt.expectedLevels[i], levels[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGeometricGridConstantRatio(t *testing.T) {
+	levels := CalculateGeometricGridLevels(20000.0, 60000.0, 30)
+	ratio := levels[1] / levels[0]
+	for i := 2; i < len(levels); i++ {
+		if got := levels[i] / levels[i-1]; math.Abs(got-ratio) > 1e-9 {
+			t.Errorf("Ratio between levels %d and %d is %v, want %v", i-1, i, got, ratio)
+		}
+	}
+}
+
+func TestParseSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Spacing
+		wantErr bool
+	}{
+		{name: "Arithmetic", input: "arithmetic", want: SpacingArithmetic},
+		{name: "Geometric", input: "geometric", want: SpacingGeometric},
+		{name: "Unknown", input: "log", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSpacing(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseSpacing(%q) = %q, %v; want %q, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
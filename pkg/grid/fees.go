@@ -0,0 +1,113 @@
+package grid
+
+import (
+	"fmt"
+)
+
+// DefaultFeeRate is Binance's standard spot maker and taker fee
+const DefaultFeeRate = 0.001
+
+// Fees describes the trading fees charged on grid orders
+type Fees struct {
+	MakerRate   float64 `json:"makerRate"`             // Fee rate for resting limit orders, e.g. 0.001 for 0.1%
+	TakerRate   float64 `json:"takerRate"`             // Fee rate for orders that take liquidity
+	BNBDiscount float64 `json:"bnbDiscount,omitempty"` // Fraction taken off the fees when paying them in BNB, e.g. 0.25
+}
+
+// Validate checks that the fee rates and discount are in range
+func (f Fees) Validate() error {
+	if f.MakerRate < 0 || f.MakerRate >= 1 || f.TakerRate < 0 || f.TakerRate >= 1 {
+		return fmt.Errorf("fee rates must be at least 0 and below 1")
+	}
+	if f.BNBDiscount < 0 || f.BNBDiscount > 1 {
+		return fmt.Errorf("BNB discount must be between 0 and 1")
+	}
+	return nil
+}
+
+// Maker returns the maker fee rate after the BNB discount
+func (f Fees) Maker() float64 {
+	return f.MakerRate * (1 - f.BNBDiscount)
+}
+
+// Taker returns the taker fee rate after the BNB discount
+func (f Fees) Taker() float64 {
+	return f.TakerRate * (1 - f.BNBDiscount)
+}
+
+// ProfitPolicy determines what happens to a grid whose round trips earn less
+// than the required minimum after fees
+type ProfitPolicy string
+
+const (
+	// ProfitPolicyReject refuses to trade the grid
+	ProfitPolicyReject ProfitPolicy = "reject"
+	// ProfitPolicyWarn trades the grid anyway and only reports the problem
+	ProfitPolicyWarn ProfitPolicy = "warn"
+)
+
+// ParseProfitPolicy converts a policy name into a ProfitPolicy
+func ParseProfitPolicy(name string) (ProfitPolicy, error) {
+	switch policy := ProfitPolicy(name); policy {
+	case ProfitPolicyReject, ProfitPolicyWarn:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown profit policy %q, expected %q or %q",
+		name, ProfitPolicyReject, ProfitPolicyWarn)
+}
+
+// GridProfits returns the net profit of a round trip in each grid, buying at
+// one level and selling the same quantity at the next, after paying the maker
+// fee on both orders. Profits are fractions of the buy value, so the result
+// has one entry fewer than levels.
+func GridProfits(levels []float64, fees Fees) []float64 {
+	if len(levels) < 2 {
+		return nil
+	}
+	rate := fees.Maker()
+	profits := make([]float64, len(levels)-1)
+	for i := range profits {
+		buy, sell := levels[i], levels[i+1]
+		profits[i] = (sell-buy)/buy - rate*(1+sell/buy)
+	}
+	return profits
+}
+
+// UnprofitableGridError reports the grid that earns least per round trip when
+// it falls short of the required minimum
+type UnprofitableGridError struct {
+	Lower     float64 // Buy level of the grid
+	Upper     float64 // Sell level of the grid
+	Profit    float64 // Net profit per round trip, as a fraction of the buy value
+	MinProfit float64 // Required net profit
+	Count     int     // Number of grids below the minimum
+}
+
+func (e *UnprofitableGridError) Error() string {
+	return fmt.Sprintf("%d grids earn less than %.4f%% per round trip after fees, worst is %v-%v at %.4f%%; "+
+		"widen the grid spacing or reduce the number of grids",
+		e.Count, e.MinProfit*100, e.Lower, e.Upper, e.Profit*100)
+}
+
+// ValidateGridProfit checks that a round trip in every grid nets at least
+// minProfit, as a fraction of the buy value, after fees. It returns an
+// *UnprofitableGridError describing the worst grid otherwise.
+func ValidateGridProfit(levels []float64, fees Fees, minProfit float64) error {
+	var worst *UnprofitableGridError
+	for i, profit := range GridProfits(levels, fees) {
+		if profit >= minProfit {
+			continue
+		}
+		if worst == nil {
+			worst = &UnprofitableGridError{MinProfit: minProfit}
+		}
+		worst.Count++
+		if worst.Count == 1 || profit < worst.Profit {
+			worst.Lower, worst.Upper, worst.Profit = levels[i], levels[i+1], profit
+		}
+	}
+	if worst != nil {
+		return worst
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package grid
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestGridProfits(t *testing.T) {
+	fees := Fees{MakerRate: 0.001, TakerRate: 0.001}
+	profits := GridProfits([]float64{100, 101, 102.01}, fees)
+
+	// 1% gross, minus 0.1% on the buy and 0.101% on the sell
+	want := 0.01 - 0.001*2.01
+	if len(profits) != 2 {
+		t.Fatalf("Expected 2 grid profits, got %v", profits)
+	}
+	for i, got := range profits {
+		if math.Abs(got-want) > 1e-12 {
+			t.Errorf("Grid %d profit = %v, want %v", i, got, want)
+		}
+	}
+
+	// A 25% BNB discount cuts the fees by a quarter
+	fees.BNBDiscount = 0.25
+	if got, want := GridProfits([]float64{100, 101}, fees)[0], 0.01-0.00075*2.01; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Discounted profit = %v, want %v", got, want)
+	}
+}
+
+func TestValidateGridProfit(t *testing.T) {
+	fees := Fees{MakerRate: 0.001, TakerRate: 0.001}
+
+	tests := []struct {
+		name      string
+		levels    []float64
+		minProfit float64
+		wantCount int // Grids below the minimum, 0 for no error
+		wantLower float64
+	}{
+		{name: "Wide grid", levels: []float64{25000, 27500, 30000}},
+		{name: "Fees exceed spacing", levels: []float64{30000, 30030, 30100}, wantCount: 1, wantLower: 30000},
+		{name: "Below minimum", levels: []float64{30000, 30090, 30300}, minProfit: 0.002, wantCount: 1, wantLower: 30000},
+		{name: "Worst of several", levels: []float64{30000, 30050, 30080}, wantCount: 2, wantLower: 30050},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGridProfit(tt.levels, fees, tt.minProfit)
+			if tt.wantCount == 0 {
+				if err != nil {
+					t.Errorf("ValidateGridProfit() error = %v", err)
+				}
+				return
+			}
+
+			var unprofitable *UnprofitableGridError
+			if !errors.As(err, &unprofitable) {
+				t.Fatalf("Expected *UnprofitableGridError, got %v", err)
+			}
+			if unprofitable.Count != tt.wantCount || unprofitable.Lower != tt.wantLower {
+				t.Errorf("Got %d grids with worst at %v, want %d at %v",
+					unprofitable.Count, unprofitable.Lower, tt.wantCount, tt.wantLower)
+			}
+		})
+	}
+}
+
+func TestFeesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fees    Fees
+		wantErr bool
+	}{
+		{name: "Standard", fees: Fees{MakerRate: 0.001, TakerRate: 0.001, BNBDiscount: 0.25}},
+		{name: "No fees", fees: Fees{}},
+		{name: "Negative rate", fees: Fees{MakerRate: -0.001}, wantErr: true},
+		{name: "Discount above 1", fees: Fees{MakerRate: 0.001, BNBDiscount: 1.5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fees.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// roundingEpsilon absorbs floating point error when snapping to an increment
+const roundingEpsilon = 1e-9
+
+// RoundToTick rounds price to the nearest multiple of tickSize.
+// A non-positive tickSize leaves price unchanged.
+func RoundToTick(price, tickSize float64) float64 {
+	if tickSize <= 0 {
+		return price
+	}
+	return cleanDecimals(math.Round(price/tickSize)*tickSize, tickSize)
+}
+
+// FloorToStep rounds quantity down to a multiple of stepSize.
+// A non-positive stepSize leaves quantity unchanged.
+func FloorToStep(quantity, stepSize float64) float64 {
+	if stepSize <= 0 {
+		return quantity
+	}
+	return cleanDecimals(math.Floor(quantity/stepSize+roundingEpsilon)*stepSize, stepSize)
+}
+
+// CeilToStep rounds quantity up to a multiple of stepSize.
+// A non-positive stepSize leaves quantity unchanged.
+func CeilToStep(quantity, stepSize float64) float64 {
+	if stepSize <= 0 {
+		return quantity
+	}
+	return cleanDecimals(math.Ceil(quantity/stepSize-roundingEpsilon)*stepSize, stepSize)
+}
+
+// Decimals returns the number of decimal places in an increment such as a
+// tick or step size, e.g. 2 for 0.01. It returns 8 for a non-positive increment.
+func Decimals(increment float64) int {
+	if increment <= 0 {
+		return 8
+	}
+	s := strconv.FormatFloat(increment, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// cleanDecimals drops floating point noise beyond the increment's precision
+func cleanDecimals(value, increment float64) float64 {
+	scale := math.Pow10(Decimals(increment))
+	return math.Round(value*scale) / scale
+}
+
+// RoundLevels rounds every grid level to tickSize. It fails if two levels
+// collapse onto the same tick, meaning the grid is too dense for the symbol.
+func RoundLevels(levels []float64, tickSize float64) ([]float64, error) {
+	rounded := make([]float64, len(levels))
+	for i, level := range levels {
+		rounded[i] = RoundToTick(level, tickSize)
+		if rounded[i] <= 0 {
+			return nil, fmt.Errorf("grid level %v rounds to a non-positive price at tick size %v", level, tickSize)
+		}
+		if i > 0 && rounded[i] <= rounded[i-1] {
+			return nil, fmt.Errorf("grid levels %v and %v are closer than the tick size %v",
+				levels[i-1], level, tickSize)
+		}
+	}
+	return rounded, nil
+}
+
+// ValidateMinNotional checks that the order at every level, sized by the
+// matching entry in quantities, is worth at least minNotional
+func ValidateMinNotional(levels, quantities []float64, minNotional float64) error {
+	if len(levels) != len(quantities) {
+		return fmt.Errorf("got %d quantities for %d levels", len(quantities), len(levels))
+	}
+	for i, level := range levels {
+		if notional := level * quantities[i]; notional < minNotional {
+			return fmt.Errorf("order at level %v is worth %.8f, below the minimum notional %v; "+
+				"increase the investment or reduce the number of grids", level, notional, minNotional)
+		}
+	}
+	return nil
+}
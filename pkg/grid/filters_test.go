@@ -0,0 +1,149 @@
+package grid
+
+import (
+	"testing"
+)
+
+func TestRoundToTick(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    float64
+		tickSize float64
+		want     float64
+	}{
+		{name: "Rounds down", price: 27500.123, tickSize: 0.01, want: 27500.12},
+		{name: "Rounds up", price: 27500.125001, tickSize: 0.01, want: 27500.13},
+		{name: "Whole ticks", price: 27503.0, tickSize: 5.0, want: 27505.0},
+		{name: "Unrestricted", price: 27500.123456789, tickSize: 0, want: 27500.123456789},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundToTick(tt.price, tt.tickSize); got != tt.want {
+				t.Errorf("RoundToTick(%v, %v) = %v, want %v", tt.price, tt.tickSize, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloorToStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		stepSize float64
+		want     float64
+	}{
+		{name: "Rounds down", quantity: 0.0123456, stepSize: 0.00001, want: 0.01234},
+		{name: "Exact multiple", quantity: 0.3, stepSize: 0.1, want: 0.3},
+		{name: "Below step", quantity: 0.00000099, stepSize: 0.000001, want: 0},
+		{name: "Unrestricted", quantity: 0.0123456, stepSize: 0, want: 0.0123456},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FloorToStep(tt.quantity, tt.stepSize); got != tt.want {
+				t.Errorf("FloorToStep(%v, %v) = %v, want %v", tt.quantity, tt.stepSize, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCeilToStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		stepSize float64
+		want     float64
+	}{
+		{name: "Rounds up", quantity: 0.0123416, stepSize: 0.00001, want: 0.01235},
+		{name: "Exact multiple", quantity: 0.3, stepSize: 0.1, want: 0.3},
+		{name: "Below step", quantity: 0.00000001, stepSize: 0.000001, want: 0.000001},
+		{name: "Unrestricted", quantity: 0.0123456, stepSize: 0, want: 0.0123456},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CeilToStep(tt.quantity, tt.stepSize); got != tt.want {
+				t.Errorf("CeilToStep(%v, %v) = %v, want %v", tt.quantity, tt.stepSize, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		levels   []float64
+		tickSize float64
+		want     []float64
+		wantErr  bool
+	}{
+		{
+			name:     "Rounds to tick",
+			levels:   []float64{100.0, 133.33333333, 166.66666667, 200.0},
+			tickSize: 0.01,
+			want:     []float64{100.0, 133.33, 166.67, 200.0},
+		},
+		{
+			name:     "Levels collapse onto one tick",
+			levels:   []float64{100.0, 100.004, 100.008},
+			tickSize: 0.01,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RoundLevels(tt.levels, tt.tickSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RoundLevels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Level %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMinNotional(t *testing.T) {
+	tests := []struct {
+		name        string
+		levels      []float64
+		quantities  []float64
+		minNotional float64
+		wantErr     bool
+	}{
+		{
+			name:        "All levels above minimum",
+			levels:      []float64{100.0, 200.0},
+			quantities:  []float64{0.1, 0.05},
+			minNotional: 5.0,
+			wantErr:     false,
+		},
+		{
+			name:        "One level below minimum",
+			levels:      []float64{100.0, 200.0},
+			quantities:  []float64{0.04, 0.05},
+			minNotional: 5.0,
+			wantErr:     true,
+		},
+		{
+			name:        "Mismatched lengths",
+			levels:      []float64{100.0, 200.0},
+			quantities:  []float64{0.1},
+			minNotional: 5.0,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMinNotional(tt.levels, tt.quantities, tt.minNotional)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMinNotional() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// LevelGenerator produces the price levels of a grid in ascending order
+type LevelGenerator interface {
+	Levels() ([]float64, error)
+}
+
+// ArithmeticGenerator places levels a constant price difference apart
+type ArithmeticGenerator struct {
+	LowerPrice float64
+	UpperPrice float64
+	GridNum    int
+}
+
+// Levels returns the grid levels
+func (g ArithmeticGenerator) Levels() ([]float64, error) {
+	return CalculateGridLevelsWithSpacing(g.LowerPrice, g.UpperPrice, g.GridNum, SpacingArithmetic)
+}
+
+// GeometricGenerator places levels a constant ratio apart
+type GeometricGenerator struct {
+	LowerPrice float64
+	UpperPrice float64
+	GridNum    int
+}
+
+// Levels returns the grid levels
+func (g GeometricGenerator) Levels() ([]float64, error) {
+	return CalculateGridLevelsWithSpacing(g.LowerPrice, g.UpperPrice, g.GridNum, SpacingGeometric)
+}
+
+// ExplicitGenerator uses a fixed list of price levels
+type ExplicitGenerator struct {
+	Prices []float64
+}
+
+// Levels returns the configured prices sorted in ascending order
+func (g ExplicitGenerator) Levels() ([]float64, error) {
+	if len(g.Prices) < 2 {
+		return nil, fmt.Errorf("at least 2 price levels are required")
+	}
+
+	levels := append([]float64(nil), g.Prices...)
+	sort.Float64s(levels)
+	for i, level := range levels {
+		if level <= 0 {
+			return nil, fmt.Errorf("prices must be positive")
+		}
+		if i > 0 && level == levels[i-1] {
+			return nil, fmt.Errorf("duplicate price level %v", level)
+		}
+	}
+
+	return levels, nil
+}
+
+// WeightedGenerator places levels densely around CenterPrice and sparsely
+// towards the bounds. Concentration controls the density profile: 1 spaces
+// levels evenly on each side of the center, larger values pull them closer to
+// the center. The bounds are always levels.
+type WeightedGenerator struct {
+	LowerPrice    float64
+	UpperPrice    float64
+	GridNum       int
+	CenterPrice   float64
+	Concentration float64
+}
+
+// Levels returns the grid levels
+func (g WeightedGenerator) Levels() ([]float64, error) {
+	if err := ValidateGridParams(g.LowerPrice, g.UpperPrice, g.GridNum); err != nil {
+		return nil, err
+	}
+	if g.CenterPrice <= g.LowerPrice || g.CenterPrice >= g.UpperPrice {
+		return nil, fmt.Errorf("center price must be between the lower and upper price")
+	}
+	if g.Concentration < 1 {
+		return nil, fmt.Errorf("concentration must be at least 1")
+	}
+
+	levels := make([]float64, g.GridNum)
+	for i := range levels {
+		// Position in [-1, 1], compressed towards 0 by the concentration
+		t := -1 + 2*float64(i)/float64(g.GridNum-1)
+		x := math.Copysign(math.Pow(math.Abs(t), g.Concentration), t)
+
+		if x < 0 {
+			levels[i] = g.CenterPrice + x*(g.CenterPrice-g.LowerPrice)
+		} else {
+			levels[i] = g.CenterPrice + x*(g.UpperPrice-g.CenterPrice)
+		}
+	}
+	// Pin the bounds to avoid floating point drift
+	levels[0], levels[g.GridNum-1] = g.LowerPrice, g.UpperPrice
+
+	return levels, nil
+}
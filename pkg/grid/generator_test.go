@@ -0,0 +1,123 @@
+package grid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLevelGenerators(t *testing.T) {
+	tests := []struct {
+		name           string
+		generator      LevelGenerator
+		expectedLevels []float64
+		wantErr        bool
+	}{
+		{
+			name:           "Arithmetic",
+			generator:      ArithmeticGenerator{LowerPrice: 100.0, UpperPrice: 200.0, GridNum: 5},
+			expectedLevels: []float64{100.0, 125.0, 150.0, 175.0, 200.0},
+		},
+		{
+			name:           "Geometric",
+			generator:      GeometricGenerator{LowerPrice: 100.0, UpperPrice: 400.0, GridNum: 3},
+			expectedLevels: []float64{100.0, 200.0, 400.0},
+		},
+		{
+			name:           "Explicit levels are sorted",
+			generator:      ExplicitGenerator{Prices: []float64{150.0, 100.0, 120.0, 200.0}},
+			expectedLevels: []float64{100.0, 120.0, 150.0, 200.0},
+		},
+		{
+			name:      "Explicit levels with duplicates",
+			generator: ExplicitGenerator{Prices: []float64{100.0, 150.0, 100.0}},
+			wantErr:   true,
+		},
+		{
+			name:      "Explicit single level",
+			generator: ExplicitGenerator{Prices: []float64{100.0}},
+			wantErr:   true,
+		},
+		{
+			name:      "Explicit non-positive level",
+			generator: ExplicitGenerator{Prices: []float64{0.0, 100.0}},
+			wantErr:   true,
+		},
+		{
+			name: "Weighted with concentration 1 is even on each side",
+			generator: WeightedGenerator{
+				LowerPrice: 100.0, UpperPrice: 300.0, GridNum: 5, CenterPrice: 200.0, Concentration: 1,
+			},
+			expectedLevels: []float64{100.0, 150.0, 200.0, 250.0, 300.0},
+		},
+		{
+			name: "Weighted with concentration 2 clusters around center",
+			generator: WeightedGenerator{
+				LowerPrice: 100.0, UpperPrice: 300.0, GridNum: 5, CenterPrice: 200.0, Concentration: 2,
+			},
+			expectedLevels: []float64{100.0, 175.0, 200.0, 225.0, 300.0},
+		},
+		{
+			name: "Weighted with asymmetric center",
+			generator: WeightedGenerator{
+				LowerPrice: 100.0, UpperPrice: 500.0, GridNum: 5, CenterPrice: 200.0, Concentration: 2,
+			},
+			expectedLevels: []float64{100.0, 175.0, 200.0, 275.0, 500.0},
+		},
+		{
+			name: "Weighted center outside range",
+			generator: WeightedGenerator{
+				LowerPrice: 100.0, UpperPrice: 300.0, GridNum: 5, CenterPrice: 400.0, Concentration: 2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Weighted concentration below 1",
+			generator: WeightedGenerator{
+				LowerPrice: 100.0, UpperPrice: 300.0, GridNum: 5, CenterPrice: 200.0, Concentration: 0.5,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels, err := tt.generator.Levels()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Levels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(levels) != len(tt.expectedLevels) {
+				t.Errorf("Expected %d levels, got %d", len(tt.expectedLevels), len(levels))
+				return
+			}
+
+			for i := range levels {
+				if math.Abs(levels[i]-tt.expectedLevels[i]) > 1e-9 {
+					t.Errorf("Level %d: expected %.2f, got %.2f", i, tt.expectedLevels[i], levels[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWeightedGeneratorDensity(t *testing.T) {
+	levels, err := WeightedGenerator{
+		LowerPrice: 20000.0, UpperPrice: 40000.0, GridNum: 21, CenterPrice: 30000.0, Concentration: 2,
+	}.Levels()
+	if err != nil {
+		t.Fatalf("Levels() error = %v", err)
+	}
+
+	// Gaps grow from the center towards both bounds
+	center := len(levels) / 2
+	for i := center + 1; i < len(levels)-1; i++ {
+		if levels[i+1]-levels[i] <= levels[i]-levels[i-1] {
+			t.Errorf("Gap above level %d is not wider than the gap below it", i)
+		}
+	}
+	for i := center - 1; i > 0; i-- {
+		if levels[i]-levels[i-1] <= levels[i+1]-levels[i] {
+			t.Errorf("Gap below level %d is not wider than the gap above it", i)
+		}
+	}
+}
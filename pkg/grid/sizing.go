@@ -0,0 +1,95 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+)
+
+// Sizing determines how the investment is split across grid levels
+type Sizing string
+
+const (
+	// SizingEqualQuote commits the same quote amount at every level
+	SizingEqualQuote Sizing = "equal-quote"
+	// SizingEqualBase trades the same base quantity at every level
+	SizingEqualBase Sizing = "equal-base"
+	// SizingMartingale grows the quote amount by a multiplier for every
+	// level away from the center of the grid, so the largest orders sit at
+	// the edges of the range
+	SizingMartingale Sizing = "martingale"
+)
+
+// investmentTolerance absorbs floating point error when comparing the
+// committed total with the investment
+const investmentTolerance = 1e-9
+
+// ParseSizing converts a sizing name into a Sizing
+func ParseSizing(name string) (Sizing, error) {
+	switch sizing := Sizing(name); sizing {
+	case SizingEqualQuote, SizingEqualBase, SizingMartingale:
+		return sizing, nil
+	}
+	return "", fmt.Errorf("unknown position sizing %q, expected %q, %q or %q",
+		name, SizingEqualQuote, SizingEqualBase, SizingMartingale)
+}
+
+// CalculateQuantities splits investment across levels and returns the base
+// quantity of an order at each level, floored to stepSize. An empty sizing
+// defaults to equal quote. multiplier is the per-level growth used by
+// martingale sizing and must be at least 1.
+func CalculateQuantities(levels []float64, investment float64, sizing Sizing, multiplier, stepSize float64) ([]float64, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("no grid levels to size")
+	}
+	if investment <= 0 {
+		return nil, fmt.Errorf("investment must be positive")
+	}
+
+	// Quote amount committed at each level, as a share of the investment
+	weights := make([]float64, len(levels))
+	switch sizing {
+	case "", SizingEqualQuote:
+		for i := range weights {
+			weights[i] = 1
+		}
+	case SizingEqualBase:
+		copy(weights, levels)
+	case SizingMartingale:
+		if multiplier < 1 {
+			return nil, fmt.Errorf("martingale multiplier must be at least 1, got %v", multiplier)
+		}
+		center := float64(len(levels)-1) / 2
+		for i := range weights {
+			weights[i] = math.Pow(multiplier, math.Abs(float64(i)-center))
+		}
+	default:
+		return nil, fmt.Errorf("unknown position sizing %q", sizing)
+	}
+
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+
+	quantities := make([]float64, len(levels))
+	for i, level := range levels {
+		quantities[i] = FloorToStep(investment*weights[i]/total/level, stepSize)
+	}
+	return quantities, nil
+}
+
+// ValidateInvestment checks that orders at every level, sized by the
+// matching entry in quantities, together cost no more than investment
+func ValidateInvestment(levels, quantities []float64, investment float64) error {
+	if len(levels) != len(quantities) {
+		return fmt.Errorf("got %d quantities for %d levels", len(quantities), len(levels))
+	}
+	var total float64
+	for i, level := range levels {
+		total += level * quantities[i]
+	}
+	if total > investment*(1+investmentTolerance) {
+		return fmt.Errorf("grid orders are worth %.8f in total, more than the investment %v", total, investment)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package grid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateQuantities(t *testing.T) {
+	levels := []float64{100, 200, 300, 400, 500}
+
+	tests := []struct {
+		name       string
+		sizing     Sizing
+		multiplier float64
+		stepSize   float64
+		wantQuote  []float64 // Quote amount committed at each level
+		wantErr    bool
+	}{
+		{
+			name:      "Equal quote",
+			sizing:    SizingEqualQuote,
+			wantQuote: []float64{300, 300, 300, 300, 300},
+		},
+		{
+			name:      "Defaults to equal quote",
+			wantQuote: []float64{300, 300, 300, 300, 300},
+		},
+		{
+			name:      "Equal base",
+			sizing:    SizingEqualBase,
+			wantQuote: []float64{100, 200, 300, 400, 500},
+		},
+		{
+			name:       "Martingale grows toward the edges",
+			sizing:     SizingMartingale,
+			multiplier: 2,
+			wantQuote:  []float64{4 * 1500.0 / 13, 2 * 1500.0 / 13, 1500.0 / 13, 2 * 1500.0 / 13, 4 * 1500.0 / 13},
+		},
+		{
+			name:       "Martingale multiplier below one",
+			sizing:     SizingMartingale,
+			multiplier: 0.5,
+			wantErr:    true,
+		},
+		{
+			name:     "Floored to step size",
+			sizing:   SizingEqualQuote,
+			stepSize: 1,
+			// 300 quote buys 3, 1.5, 1, 0.75 and 0.6 base
+			wantQuote: []float64{300, 200, 300, 0, 0},
+		},
+		{
+			name:    "Unknown sizing",
+			sizing:  "kelly",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quantities, err := CalculateQuantities(levels, 1500, tt.sizing, tt.multiplier, tt.stepSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateQuantities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, want := range tt.wantQuote {
+				if got := quantities[i] * levels[i]; math.Abs(got-want) > 1e-9 {
+					t.Errorf("Level %v: quote amount = %v, want %v", levels[i], got, want)
+				}
+			}
+			if err := ValidateInvestment(levels, quantities, 1500); err != nil {
+				t.Errorf("ValidateInvestment() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateInvestment(t *testing.T) {
+	levels := []float64{100, 200}
+
+	if err := ValidateInvestment(levels, []float64{1, 1}, 300); err != nil {
+		t.Errorf("Expected total equal to the investment to pass, got %v", err)
+	}
+	if err := ValidateInvestment(levels, []float64{1, 1.01}, 300); err == nil {
+		t.Error("Expected total above the investment to fail")
+	}
+	if err := ValidateInvestment(levels, []float64{1}, 300); err == nil {
+		t.Error("Expected mismatched quantities to fail")
+	}
+}
@@ -0,0 +1,158 @@
+// Package metrics exports the bot's metrics to Prometheus
+package metrics
+
+import (
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latencyBuckets are histogram buckets in seconds suited to API calls
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RequestMetrics records the latency and errors of exchange API requests. It
+// implements exchange.RequestObserver.
+type RequestMetrics struct {
+	duration *prometheus.HistogramVec
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+}
+
+// NewRequestMetrics registers the exchange API request metrics
+func NewRequestMetrics(r prometheus.Registerer) *RequestMetrics {
+	m := &RequestMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grid_bot_api_request_duration_seconds",
+			Help:    "Latency of exchange API requests.",
+			Buckets: latencyBuckets,
+		}, []string{"method", "endpoint"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grid_bot_api_requests_total",
+			Help: "Exchange API requests made.",
+		}, []string{"method", "endpoint"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grid_bot_api_request_errors_total",
+			Help: "Exchange API requests that failed or returned an error status.",
+		}, []string{"method", "endpoint"}),
+	}
+	r.MustRegister(m.duration, m.requests, m.errors)
+	return m
+}
+
+// ObserveRequest records a completed request
+func (m *RequestMetrics) ObserveRequest(method, endpoint string, duration time.Duration, err error) {
+	m.duration.WithLabelValues(method, endpoint).Observe(duration.Seconds())
+	m.requests.WithLabelValues(method, endpoint).Inc()
+	// Expose the error series before the first error
+	failed := m.errors.WithLabelValues(method, endpoint)
+	if err != nil {
+		failed.Inc()
+	}
+}
+
+// Descriptions of the metrics read from the bot's status
+var (
+	runningDesc       = prometheus.NewDesc("grid_bot_running", "Whether the bot is running, 1 or 0.", nil, nil)
+	pausedDesc        = prometheus.NewDesc("grid_bot_paused", "Whether the bot is paused, 1 or 0.", nil, nil)
+	stateDesc         = prometheus.NewDesc("grid_bot_state", "Lifecycle state of the bot, 1 for the current state and 0 for the others.", []string{"state"}, nil)
+	outOfRangeDesc    = prometheus.NewDesc("grid_bot_out_of_range", "Whether the last price is outside the grid, 1 or 0.", nil, nil)
+	openOrdersDesc    = prometheus.NewDesc("grid_bot_open_orders", "Open grid orders.", []string{"side"}, nil)
+	fillsDesc         = prometheus.NewDesc("grid_bot_fills_total", "Filled grid orders.", []string{"side"}, nil)
+	roundTripsDesc    = prometheus.NewDesc("grid_bot_round_trips_total", "Sells paired with a grid buy one level below.", nil, nil)
+	lastPriceDesc     = prometheus.NewDesc("grid_bot_last_price", "Latest known price in quote currency.", nil, nil)
+	lastPriceTimeDesc = prometheus.NewDesc("grid_bot_last_price_timestamp_seconds", "When the last price was observed.", nil, nil)
+	lastFillTimeDesc  = prometheus.NewDesc("grid_bot_last_fill_timestamp_seconds", "When the last fill was handled.", nil, nil)
+	realizedPnLDesc   = prometheus.NewDesc("grid_bot_realized_pnl", "Realized profit in quote currency, before fees.", nil, nil)
+	unrealizedPnLDesc = prometheus.NewDesc("grid_bot_unrealized_pnl", "Inventory valued at the last price minus its cost basis.", nil, nil)
+	netPnLDesc        = prometheus.NewDesc("grid_bot_net_pnl", "Realized plus unrealized profit after fees.", nil, nil)
+	feesDesc          = prometheus.NewDesc("grid_bot_fees_total", "Fees paid, converted to quote currency.", nil, nil)
+	inventoryDesc     = prometheus.NewDesc("grid_bot_inventory", "Base asset held for the grid.", nil, nil)
+	averageCostDesc   = prometheus.NewDesc("grid_bot_inventory_average_cost", "Average price paid for the inventory.", nil, nil)
+)
+
+// botCollector reads the bot's metrics from its status on every scrape
+type botCollector struct {
+	bot *bot.GridBot
+}
+
+// RegisterBot registers metrics read from the bot's status on every scrape
+func RegisterBot(r prometheus.Registerer, gridBot *bot.GridBot) {
+	r.MustRegister(botCollector{bot: gridBot})
+}
+
+// Describe implements prometheus.Collector
+func (c botCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		runningDesc, pausedDesc, stateDesc, outOfRangeDesc, openOrdersDesc, fillsDesc, roundTripsDesc,
+		lastPriceDesc, lastPriceTimeDesc, lastFillTimeDesc, realizedPnLDesc, unrealizedPnLDesc, netPnLDesc,
+		feesDesc, inventoryDesc, averageCostDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c botCollector) Collect(ch chan<- prometheus.Metric) {
+	status := c.bot.GetStatus()
+	gauge := func(desc *prometheus.Desc, value float64, labelValues ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...)
+	}
+	counter := func(desc *prometheus.Desc, value float64, labelValues ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labelValues...)
+	}
+
+	gauge(runningDesc, boolValue(status.Running))
+	gauge(pausedDesc, boolValue(status.Paused))
+	for _, s := range bot.RunStates {
+		gauge(stateDesc, boolValue(status.State == s), string(s))
+	}
+	gauge(outOfRangeDesc, boolValue(status.OutOfRange))
+
+	sides := map[string]int{"BUY": 0, "SELL": 0}
+	for _, level := range status.Levels {
+		if level.Side != "" {
+			sides[level.Side]++
+		}
+	}
+	for side, n := range sides {
+		gauge(openOrdersDesc, float64(n), side)
+	}
+
+	filled := map[string]int{"BUY": 0, "SELL": 0}
+	for _, fill := range c.bot.Fills() {
+		filled[fill.Side]++
+	}
+	for side, n := range filled {
+		counter(fillsDesc, float64(n), side)
+	}
+
+	gauge(lastPriceDesc, status.LastPrice)
+	gauge(lastPriceTimeDesc, timestamp(status.LastPriceAt))
+	gauge(lastFillTimeDesc, timestamp(status.LastFillAt))
+
+	counter(roundTripsDesc, float64(status.PnL.RoundTrips))
+	gauge(realizedPnLDesc, status.PnL.RealizedPnL)
+	gauge(unrealizedPnLDesc, status.PnL.UnrealizedPnL)
+	gauge(netPnLDesc, status.PnL.NetPnL)
+	counter(feesDesc, status.PnL.Fees)
+	gauge(inventoryDesc, status.PnL.Inventory)
+	gauge(averageCostDesc, status.PnL.AverageCost)
+}
+
+// boolValue converts a flag to a gauge value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// timestamp converts a time to Unix seconds, zero if unset
+func timestamp(t *time.Time) float64 {
+	if t == nil {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+	"spot_grid_bot/pkg/exchange"
+	"spot_grid_bot/pkg/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrape returns the metrics of r in the Prometheus text format
+func scrape(t *testing.T, r *prometheus.Registry) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	promhttp.HandlerFor(r, promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Scrape status = %d, body:\n%s", rec.Code, rec.Body)
+	}
+	return rec.Body.String()
+}
+
+func TestRegisterBot(t *testing.T) {
+	sim, err := exchange.NewSimulatedExchange(exchange.SimulatorConfig{
+		Symbol:   types.SymbolInfo{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		Balances: map[string]float64{"USDT": 1000, "BTC": 0.05},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create simulated exchange: %v", err)
+	}
+	sim.SetPrice(time.Now(), 31000)
+
+	gridBot, err := bot.NewGridBot(sim, bot.GridBotConfig{
+		Symbol:       "BTCUSDT",
+		LowerPrice:   25000,
+		UpperPrice:   35000,
+		GridNum:      5,
+		Investment:   1000,
+		PollInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	ctx := context.Background()
+	if err := gridBot.Start(ctx); err != nil {
+		t.Fatalf("Failed to start bot: %v", err)
+	}
+	defer gridBot.Stop(ctx)
+
+	// A buy fills, placing a sell one level up
+	sim.SetPrice(time.Now(), 27000)
+	gridBot.CheckOrders(ctx)
+
+	r := prometheus.NewRegistry()
+	RegisterBot(r, gridBot)
+	out := scrape(t, r)
+
+	for _, want := range []string{
+		"grid_bot_running 1\n",
+		"grid_bot_paused 0\n",
+		`grid_bot_state{state="running"} 1` + "\n",
+		`grid_bot_state{state="paused"} 0` + "\n",
+		`grid_bot_open_orders{side="BUY"} 1` + "\n",
+		`grid_bot_open_orders{side="SELL"} 3` + "\n",
+		`grid_bot_fills_total{side="BUY"} 1` + "\n",
+		`grid_bot_fills_total{side="SELL"} 0` + "\n",
+		"grid_bot_last_price 31000\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "grid_bot_inventory 0\n") {
+		t.Errorf("Expected inventory after a buy fill:\n%s", out)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewRequestMetrics(r)
+
+	m.ObserveRequest("GET", "/api/v3/order", 20*time.Millisecond, nil)
+	m.ObserveRequest("GET", "/api/v3/order", 2*time.Second, errors.New("HTTP status 500"))
+	m.ObserveRequest("POST", "/api/v3/order", 30*time.Millisecond, nil)
+
+	out := scrape(t, r)
+
+	for _, want := range []string{
+		`grid_bot_api_requests_total{endpoint="/api/v3/order",method="GET"} 2`,
+		`grid_bot_api_request_errors_total{endpoint="/api/v3/order",method="GET"} 1`,
+		`grid_bot_api_request_errors_total{endpoint="/api/v3/order",method="POST"} 0`,
+		`grid_bot_api_request_duration_seconds_bucket{endpoint="/api/v3/order",method="GET",le="0.025"} 1`,
+		`grid_bot_api_request_duration_seconds_count{endpoint="/api/v3/order",method="POST"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Expected %q in metrics:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	stateBucket = []byte("state")
+	stateKey    = []byte("grid")
+)
+
+// BoltStore persists bot state in an embedded BoltDB database
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates the BoltDB database at path
+func OpenBoltStore(path string) (*BoltStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("state database path is required")
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(stateBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize state database: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Load reads the saved state, returning nil if nothing has been saved
+func (s *BoltStore) Load() (*bot.State, error) {
+	var state *bot.State
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(stateBucket).Get(stateKey)
+		if data == nil {
+			return nil
+		}
+		state = new(bot.State)
+		return json.Unmarshal(data, state)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return state, nil
+}
+
+// Save replaces the saved state
+func (s *BoltStore) Save(state bot.State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(stateBucket).Put(stateKey, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying database
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
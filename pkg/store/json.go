@@ -0,0 +1,80 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"spot_grid_bot/pkg/bot"
+)
+
+// JSONFileStore persists bot state as a JSON document in a single file
+type JSONFileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONFileStore creates a store that reads and writes the file at path
+func NewJSONFileStore(path string) (*JSONFileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("state file path is required")
+	}
+	return &JSONFileStore{path: path}, nil
+}
+
+// Load reads the saved state, returning nil if the file does not exist
+func (s *JSONFileStore) Load() (*bot.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state bot.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode state file: %w", err)
+	}
+	return &state, nil
+}
+
+// Save atomically replaces the state file
+func (s *JSONFileStore) Save(state bot.State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"spot_grid_bot/pkg/bot"
+)
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonStore, err := NewJSONFileStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create JSON store: %v", err)
+	}
+
+	boltStore, err := OpenBoltStore(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatalf("Failed to open Bolt store: %v", err)
+	}
+	defer boltStore.Close()
+
+	tests := []struct {
+		name  string
+		store bot.StateStore
+	}{
+		{name: "JSON file", store: jsonStore},
+		{name: "BoltDB", store: boltStore},
+	}
+
+	state := bot.State{
+		Config: bot.GridBotConfig{
+			Symbol:     "BTCUSDT",
+			LowerPrice: 25000.0,
+			UpperPrice: 35000.0,
+			GridNum:    5,
+			Investment: 1000.0,
+		},
+		Levels: []float64{25000.0, 27500.0, 30000.0, 32500.0, 35000.0},
+		Orders: []bot.OrderRecord{
+			{OrderID: "1", Level: 0, Side: "BUY", Price: 25000.0, Quantity: 0.004},
+			{OrderID: "2", Level: 4, Side: "SELL", Price: 35000.0, Quantity: 0.003},
+		},
+		Fills: []bot.Fill{
+			{OrderID: "3", Level: 3, Side: "SELL", Price: 32500.0, Quantity: 0.003, Time: time.Unix(1700000000, 0).UTC()},
+		},
+		Ledger:    bot.Ledger{RealizedPnL: 7.5, RoundTrips: 1},
+		UpdatedAt: time.Unix(1700000100, 0).UTC(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := tt.store.Load()
+			if err != nil {
+				t.Fatalf("Load() on empty store error = %v", err)
+			}
+			if loaded != nil {
+				t.Fatalf("Expected nil state from empty store, got %+v", loaded)
+			}
+
+			if err := tt.store.Save(state); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			loaded, err = tt.store.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(*loaded, state) {
+				t.Errorf("Load() = %+v, want %+v", *loaded, state)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package types
+
+import "errors"
+
+// Errors exchanges wrap their failures in, so callers can tell failures worth
+// retrying from ones that are not
+var (
+	// ErrRateLimited is a request rejected by the exchange's rate limits
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable is a request that timed out, could not reach the
+	// exchange or failed with a server error. Its outcome may be unknown.
+	ErrUnavailable = errors.New("exchange unavailable")
+	// ErrInsufficientBalance is an order the account cannot fund
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrOrderRejected is an order that violates the symbol's trading rules
+	// or is otherwise invalid
+	ErrOrderRejected = errors.New("order rejected")
+	// ErrDuplicateOrder is an order whose client order ID is already in use
+	ErrDuplicateOrder = errors.New("duplicate order")
+	// ErrUnknownOrder is a request for an order the exchange does not know
+	ErrUnknownOrder = errors.New("unknown order")
+)
+
+// IsRetryable reports whether err is a transient failure that may succeed
+// when retried
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
@@ -1,11 +1,67 @@
 package types
 
+import "time"
+
 // Order represents a trading order
 type Order struct {
-	Symbol      string
-	Side        string // BUY or SELL
-	Type        string // LIMIT or MARKET
-	Quantity    float64
-	Price       float64
-	TimeInForce string // GTC, IOC, FOK
+	Symbol        string
+	Side          string // BUY or SELL
+	Type          string // LIMIT or MARKET
+	Quantity      float64
+	Price         float64 // Limit price; for a MARKET order, the price it is expected to fill at
+	TimeInForce   string  // GTC, IOC, FOK
+	ClientOrderID string  // Optional client-assigned ID
+}
+
+// OrderStatus represents the lifecycle state of an order on the exchange
+type OrderStatus string
+
+// Order statuses reported by the exchange
+const (
+	OrderStatusNew             OrderStatus = "NEW"
+	OrderStatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED"
+	OrderStatusFilled          OrderStatus = "FILLED"
+	OrderStatusCanceled        OrderStatus = "CANCELED"
+	OrderStatusRejected        OrderStatus = "REJECTED"
+	OrderStatusExpired         OrderStatus = "EXPIRED"
+)
+
+// OrderInfo represents the state of an order as reported by the exchange
+type OrderInfo struct {
+	OrderID          string
+	ClientOrderID    string
+	Symbol           string
+	Side             string // BUY or SELL
+	Price            float64
+	Quantity         float64
+	ExecutedQuantity float64
+	AveragePrice     float64 // Average execution price, zero if nothing executed
+	Status           OrderStatus
+}
+
+// ExecutionReport represents an order update pushed by the exchange
+type ExecutionReport struct {
+	Symbol             string
+	OrderID            string
+	ClientOrderID      string
+	Side               string // BUY or SELL
+	Status             OrderStatus
+	Price              float64 // Order limit price
+	Quantity           float64 // Order quantity
+	LastQuantity       float64 // Quantity filled by this execution
+	LastPrice          float64 // Price of this execution
+	CumulativeQuantity float64 // Total quantity filled so far
+	Commission         float64 // Commission charged for this execution
+	CommissionAsset    string
+	RejectReason       string
+	Time               time.Time
+}
+
+// PriceUpdate represents a live top-of-book price for a symbol
+type PriceUpdate struct {
+	Symbol string
+	Bid    float64 // Best bid price, zero when unknown
+	Ask    float64 // Best ask price, zero when unknown
+	Price  float64 // Mid price, or last price when bid/ask are unknown
+	Time   time.Time
 }
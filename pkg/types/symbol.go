@@ -0,0 +1,12 @@
+package types
+
+// SymbolInfo holds the trading rules of a symbol
+type SymbolInfo struct {
+	Symbol      string
+	BaseAsset   string
+	QuoteAsset  string
+	TickSize    float64 // Price increment, zero if unrestricted
+	StepSize    float64 // Quantity increment, zero if unrestricted
+	MinQty      float64 // Minimum order quantity
+	MinNotional float64 // Minimum order value (price * quantity) in quote currency
+}